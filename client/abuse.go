@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// AbuseOptions are the query parameters accepted by the Abuse Contact API.
+type AbuseOptions struct {
+	IP       string
+	Excludes []string
+	Fields   []string
+}
+
+func (o AbuseOptions) values() url.Values {
+	query := url.Values{}
+	setString(query, "ip", o.IP)
+	setList(query, "excludes", o.Excludes)
+	setList(query, "fields", o.Fields)
+	return query
+}
+
+// Abuse looks up the abuse contact responsible for an IP address.
+func (c *Client) Abuse(ctx context.Context, opts AbuseOptions) (*Response, error) {
+	return c.get(ctx, "abuse", opts.values())
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// ASNOptions are the query parameters accepted by the ASN API.
+type ASNOptions struct {
+	IP       string
+	ASN      string
+	Include  []string
+	Excludes []string
+	Fields   []string
+}
+
+func (o ASNOptions) values() url.Values {
+	query := url.Values{}
+	setString(query, "ip", o.IP)
+	setString(query, "asn", o.ASN)
+	setList(query, "include", o.Include)
+	setList(query, "excludes", o.Excludes)
+	setList(query, "fields", o.Fields)
+	return query
+}
+
+// ASN looks up an autonomous system by number or by one of its IP addresses.
+func (c *Client) ASN(ctx context.Context, opts ASNOptions) (*Response, error) {
+	return c.get(ctx, "asn", opts.values())
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// AstronomyOptions are the query parameters accepted by the Astronomy API.
+type AstronomyOptions struct {
+	IP        string
+	Location  string
+	Latitude  float64
+	Longitude float64
+	Language  string
+	Tz        string
+	Elevation float64
+}
+
+func (o AstronomyOptions) values() url.Values {
+	query := url.Values{}
+	setString(query, "ip", o.IP)
+	setString(query, "time_zone", o.Tz)
+	setString(query, "lang", o.Language)
+	setString(query, "location", o.Location)
+	setFloat(query, "lat", o.Latitude)
+	setFloat(query, "long", o.Longitude)
+	setFloat(query, "elevation", o.Elevation)
+	return query
+}
+
+// Astronomy looks up sun and moon data for a location on the current day.
+func (c *Client) Astronomy(ctx context.Context, opts AstronomyOptions) (*Response, error) {
+	return c.get(ctx, "astronomy", opts.values())
+}
+
+// AstronomyTimeSeriesOptions are the query parameters accepted by the
+// Astronomy Time Series API. DateStart and DateEnd are required.
+type AstronomyTimeSeriesOptions struct {
+	IP        string
+	Location  string
+	Latitude  float64
+	Longitude float64
+	Language  string
+	DateStart string
+	DateEnd   string
+}
+
+func (o AstronomyTimeSeriesOptions) values() url.Values {
+	query := url.Values{}
+	setString(query, "dateStart", o.DateStart)
+	setString(query, "dateEnd", o.DateEnd)
+	setString(query, "ip", o.IP)
+	setString(query, "lang", o.Language)
+	setString(query, "location", o.Location)
+	setFloat(query, "lat", o.Latitude)
+	setFloat(query, "long", o.Longitude)
+	return query
+}
+
+// AstronomyTimeSeries looks up sun and moon data for every day in a date range.
+func (c *Client) AstronomyTimeSeries(ctx context.Context, opts AstronomyTimeSeriesOptions) (*Response, error) {
+	return c.get(ctx, "astronomy/timeSeries", opts.values())
+}
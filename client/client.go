@@ -0,0 +1,240 @@
+// Package client is a small typed client for the ipgeolocation.io v3 API.
+// It is used by every command of the ipgeolocation CLI and can be imported
+// by other Go programs as well.
+// For more details visit: https://ipgeolocation.io/cli/ipgeolocation
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the ipgeolocation.io API root used when none is set.
+const DefaultBaseURL = "https://api.ipgeolocation.io/v3"
+
+// DefaultTimeout bounds a single HTTP request made by a Client from New.
+const DefaultTimeout = 30 * time.Second
+
+// Client sends requests to the ipgeolocation.io API.
+type Client struct {
+	APIKey     string
+	BaseURL    string
+	HTTPClient *http.Client
+	Retry      RetryPolicy
+
+	// Logf, when set, receives diagnostic messages such as retry attempts.
+	Logf func(format string, args ...interface{})
+}
+
+// Response holds the raw result of a successful API call.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Decode unmarshals the response body into a generic value with
+// DecodeJSON, so that objects keep the order of their fields.
+func (r *Response) Decode() (interface{}, error) {
+	result, err := DecodeJSON(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return result, nil
+}
+
+// New returns a Client for the given API key using the default base URL.
+// Set BaseURL on the returned Client to talk to a proxy, staging gateway or
+// mock server instead.
+func New(apiKey string) *Client {
+	return &Client{
+		APIKey:     apiKey,
+		BaseURL:    DefaultBaseURL,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+		Retry:      DefaultRetryPolicy(),
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values) (*Response, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+func (c *Client) post(ctx context.Context, path string, query url.Values, payload interface{}) (*Response, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request body: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, path, query, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req)
+}
+
+// newRequest builds a request without the API key, so that the request URL
+// can be logged and shown in errors. do adds the key before sending.
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	endpoint := JoinURL(c.baseURL(), path)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return c.BaseURL
+}
+
+// JoinURL appends an endpoint path to a base URL, making sure exactly one
+// slash separates them whether or not the base URL ends with one.
+func JoinURL(base, path string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// ValidateBaseURL checks that base is an absolute http or https URL.
+func ValidateBaseURL(base string) error {
+	u, err := url.Parse(base)
+	if err != nil {
+		return fmt.Errorf("invalid API URL %q: %w", base, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid API URL %q: must be an absolute http(s) URL", base)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid API URL %q: must not contain a query or fragment", base)
+	}
+	return nil
+}
+
+func (c *Client) do(req *http.Request) (*Response, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+
+		resp, err := httpClient.Do(c.authenticate(req))
+		if err != nil {
+			err = hideURL(err, req)
+			if attempt < c.Retry.MaxRetries && retryableError(req.Context(), err) {
+				if err := c.wait(req, attempt+1, nil, err.Error()); err != nil {
+					return nil, fmt.Errorf("request failed: %w", err)
+				}
+				continue
+			}
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			if attempt < c.Retry.MaxRetries && retryableStatus(resp.StatusCode) {
+				if err := c.wait(req, attempt+1, resp, resp.Status); err != nil {
+					return nil, fmt.Errorf("request failed: %w", err)
+				}
+				continue
+			}
+			return nil, newAPIError(resp.StatusCode, body)
+		}
+
+		return &Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       body,
+		}, nil
+	}
+}
+
+// authenticate returns a copy of req carrying the API key. The API only
+// accepts the key in the apiKey query parameter, so it is part of the URL
+// sent on the wire; it is only ever set on this copy, so that the URLs of
+// req, which end up in errors and logs, never include it.
+func (c *Client) authenticate(req *http.Request) *http.Request {
+	if c.APIKey == "" {
+		return req
+	}
+	authed := req.Clone(req.Context())
+	query := authed.URL.Query()
+	query.Set("apiKey", c.APIKey)
+	authed.URL.RawQuery = query.Encode()
+	return authed
+}
+
+// hideURL replaces the URL in a transport error, which includes the API
+// key, with the URL of the unauthenticated request.
+func hideURL(err error, req *http.Request) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = req.URL.String()
+	}
+	return err
+}
+
+// wait logs the upcoming retry and sleeps for its backoff delay.
+func (c *Client) wait(req *http.Request, attempt int, resp *http.Response, reason string) error {
+	delay := c.Retry.backoff(attempt, resp)
+	c.logf("retry %d/%d for %s %s in %s: %s", attempt, c.Retry.MaxRetries, req.Method, req.URL.Path, delay.Round(time.Millisecond), reason)
+	return sleep(req.Context(), delay)
+}
+
+func (c *Client) logf(format string, args ...interface{}) {
+	if c.Logf != nil {
+		c.Logf(format, args...)
+	}
+}
+
+// setList adds a comma separated query parameter when values is not empty.
+func setList(query url.Values, key string, values []string) {
+	if len(values) > 0 {
+		query.Set(key, strings.Join(values, ","))
+	}
+}
+
+// setString adds a query parameter when value is not empty.
+func setString(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// setFloat adds a query parameter when value is not zero.
+func setFloat(query url.Values, key string, value float64) {
+	if value != 0 {
+		query.Set(key, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+}
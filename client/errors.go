@@ -0,0 +1,38 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when the API answers with a status other than 200.
+type APIError struct {
+	StatusCode int
+	// Message is the "message" field of the JSON error body, or the body
+	// itself when it is not JSON.
+	Message string
+	Body    []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
+}
+
+// newAPIError builds an APIError from a failed response.
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Body: body}
+
+	var payload struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Message != "" {
+		apiErr.Message = payload.Message
+	} else if text := strings.TrimSpace(string(body)); text != "" {
+		apiErr.Message = text
+	} else {
+		apiErr.Message = http.StatusText(statusCode)
+	}
+	return apiErr
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// IPGeoOptions are the query parameters accepted by the IP Geolocation API.
+type IPGeoOptions struct {
+	IP       string
+	Include  []string
+	Excludes []string
+	Fields   []string
+	Language string
+}
+
+func (o IPGeoOptions) values() url.Values {
+	query := url.Values{}
+	setString(query, "ip", o.IP)
+	setList(query, "include", o.Include)
+	setList(query, "excludes", o.Excludes)
+	setList(query, "fields", o.Fields)
+	setString(query, "lang", o.Language)
+	return query
+}
+
+// IPGeo looks up geolocation data for a single IP address or domain.
+// An empty IP looks up the caller's own address.
+func (c *Client) IPGeo(ctx context.Context, opts IPGeoOptions) (*Response, error) {
+	return c.get(ctx, "ipgeo", opts.values())
+}
+
+// BulkIPGeo looks up geolocation data for several IP addresses in one request.
+func (c *Client) BulkIPGeo(ctx context.Context, ips []string, opts IPGeoOptions) (*Response, error) {
+	payload := map[string]interface{}{
+		"ips": ips,
+	}
+	return c.post(ctx, "ipgeo-bulk", opts.values(), payload)
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Defaults used by DefaultRetryPolicy.
+const (
+	DefaultRetries      = 2
+	DefaultRetryMaxWait = 30 * time.Second
+	defaultRetryBase    = 500 * time.Millisecond
+)
+
+// RetryPolicy controls how requests that fail with a transient transport
+// error or status (429, 500, 502, 503, 504) are retried.
+type RetryPolicy struct {
+	// MaxRetries is the number of attempts made after the first one.
+	MaxRetries int
+	// MaxWait caps the delay before a single retry, including delays
+	// requested by a Retry-After header.
+	MaxWait time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used by New.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: DefaultRetries,
+		MaxWait:    DefaultRetryMaxWait,
+	}
+}
+
+// retryableStatus reports whether a response status is worth retrying.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryableError reports whether a transport error is worth retrying.
+// Per-request timeouts, temporary network errors and refused or reset
+// connections are retried. Cancellation or a deadline on the caller's
+// context is final, as are errors that another attempt cannot fix, such as
+// an untrusted certificate, a failing proxy or a malformed URL.
+func retryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalidCert      x509.CertificateInvalidError
+		recordHeader     tls.RecordHeaderError
+		opErr            *net.OpError
+	)
+	switch {
+	case errors.As(err, &unknownAuthority),
+		errors.As(err, &hostname),
+		errors.As(err, &invalidCert),
+		errors.As(err, &recordHeader):
+		return false
+	case errors.As(err, &opErr) && opErr.Op == "proxyconnect":
+		return false
+	case errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, io.EOF):
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var temporary interface{ Temporary() bool }
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
+
+// backoff returns the delay before retry number attempt (starting at 1).
+// It doubles with every attempt and picks a random point in the upper half
+// of the window so that concurrent clients do not retry in lockstep. A
+// Retry-After header, when present, takes precedence.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	maxWait := p.MaxWait
+	if maxWait <= 0 {
+		maxWait = DefaultRetryMaxWait
+	}
+
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if wait > maxWait {
+				return maxWait
+			}
+			return wait
+		}
+	}
+
+	wait := defaultRetryBase << uint(attempt-1)
+	if wait <= 0 || wait > maxWait {
+		wait = maxWait
+	}
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter understands both forms of the Retry-After header:
+// a number of seconds and an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		wait := time.Until(at)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	future := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		value   string
+		ok      bool
+		min     time.Duration
+		max     time.Duration
+		comment string
+	}{
+		{"", false, 0, 0, "missing"},
+		{"0", true, 0, 0, "zero seconds"},
+		{"7", true, 7 * time.Second, 7 * time.Second, "delta-seconds"},
+		{"3600", true, time.Hour, time.Hour, "longer than MaxWait"},
+		{future, true, 85 * time.Second, 90 * time.Second, "HTTP-date"},
+		{past, true, 0, 0, "HTTP-date in the past"},
+		{"-5", false, 0, 0, "negative"},
+		{"1.5", false, 0, 0, "fraction"},
+		{"soon", false, 0, 0, "garbage"},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value)
+		if ok != tt.ok || got < tt.min || got > tt.max {
+			t.Errorf("%s: parseRetryAfter(%q) = %s, %v; want %s..%s, %v", tt.comment, tt.value, got, ok, tt.min, tt.max, tt.ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 5, MaxWait: 3 * time.Second}
+	for attempt, window := range map[int]time.Duration{
+		1: 500 * time.Millisecond,
+		2: time.Second,
+		3: 2 * time.Second,
+		4: 3 * time.Second, // capped by MaxWait
+		9: 3 * time.Second,
+	} {
+		for i := 0; i < 50; i++ {
+			if got := p.backoff(attempt, nil); got < window/2 || got > window {
+				t.Fatalf("backoff(%d) = %s; want %s..%s", attempt, got, window/2, window)
+			}
+		}
+	}
+
+	for value, want := range map[string]time.Duration{
+		"2":    2 * time.Second,
+		"3600": 3 * time.Second, // capped by MaxWait
+		"soon": 0,               // ignored, so the exponential delay applies
+	} {
+		resp := &http.Response{Header: http.Header{"Retry-After": {value}}}
+		got := p.backoff(1, resp)
+		if want == 0 {
+			if got < 250*time.Millisecond || got > 500*time.Millisecond {
+				t.Errorf("backoff with Retry-After %q = %s; want the exponential delay", value, got)
+			}
+		} else if got != want {
+			t.Errorf("backoff with Retry-After %q = %s; want %s", value, got, want)
+		}
+	}
+
+	if got := (RetryPolicy{}).backoff(30, nil); got > DefaultRetryMaxWait {
+		t.Errorf("backoff without MaxWait = %s; want at most %s", got, DefaultRetryMaxWait)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryableError(t *testing.T) {
+	wrap := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://api.example.com/v2/ipgeo", Err: err}
+	}
+	dial := func(err error) error {
+		return wrap(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", err)})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"timeout", wrap(timeoutError{}), true},
+		{"temporary DNS failure", wrap(&net.DNSError{Err: "server misbehaving", Name: "api.example.com", IsTemporary: true}), true},
+		{"connection refused", dial(syscall.ECONNREFUSED), true},
+		{"connection reset", wrap(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{"unknown host", wrap(&net.DNSError{Err: "no such host", Name: "api.example.com", IsNotFound: true}), false},
+		{"unknown authority", wrap(x509.UnknownAuthorityError{}), false},
+		{"wrong host name", wrap(x509.HostnameError{Certificate: &x509.Certificate{}, Host: "api.example.com"}), false},
+		{"expired certificate", wrap(x509.CertificateInvalidError{Reason: x509.Expired}), false},
+		{"proxy", wrap(&net.OpError{Op: "proxyconnect", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), false},
+		{"malformed URL", wrap(errors.New(`unsupported protocol scheme "htps"`)), false},
+	}
+	for _, tt := range tests {
+		if got := retryableError(context.Background(), tt.err); got != tt.want {
+			t.Errorf("%s: retryableError = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if retryableError(ctx, wrap(timeoutError{})) {
+		t.Error("retried a timeout after the context was canceled")
+	}
+}
+
+// newTestClient returns a client for srv that retries without waiting long.
+func newTestClient(baseURL string) *Client {
+	c := New("k-123")
+	c.BaseURL = baseURL
+	c.Retry = RetryPolicy{MaxRetries: 2, MaxWait: time.Millisecond}
+	return c
+}
+
+func TestRetryLoop(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  int // status of the expected APIError, or 0 for success
+		wantHits int32
+	}{
+		{"success", []int{200}, 0, 1},
+		{"recovers from 503", []int{503, 200}, 0, 2},
+		{"recovers from 429 then 502", []int{429, 502, 200}, 0, 3},
+		{"gives up after MaxRetries", []int{500, 500, 500, 200}, 500, 3},
+		{"does not retry 401", []int{401, 200}, 401, 1},
+		{"does not retry 400", []int{400, 200}, 400, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&hits, 1)
+				if got := r.URL.Query().Get("apiKey"); got != "k-123" {
+					t.Errorf("attempt %d: apiKey = %q", n, got)
+				}
+				status := tt.statuses[n-1]
+				if status != http.StatusOK {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				fmt.Fprintf(w, `{"message":"attempt %d"}`, n)
+			}))
+			defer srv.Close()
+
+			resp, err := newTestClient(srv.URL).get(context.Background(), "ipgeo", nil)
+			if hits != tt.wantHits {
+				t.Errorf("server saw %d requests, want %d", hits, tt.wantHits)
+			}
+			var apiErr *APIError
+			switch {
+			case tt.wantErr == 0 && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr == 0 && resp.StatusCode != http.StatusOK:
+				t.Errorf("status %d, want 200", resp.StatusCode)
+			case tt.wantErr != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantErr):
+				t.Errorf("got %v, want API error %d", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryLoopTransportErrors(t *testing.T) {
+	// A closed server refuses connections, which is retried.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	c := newTestClient(srv.URL)
+	var retries int
+	c.Logf = func(string, ...interface{}) { retries++ }
+	if _, err := c.get(context.Background(), "ipgeo", nil); err == nil {
+		t.Fatal("request to a closed server succeeded")
+	}
+	if retries != 2 {
+		t.Errorf("refused connection retried %d times, want 2", retries)
+	}
+
+	// A certificate the client does not trust fails at once.
+	var hits int32
+	tlsSrv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	tlsSrv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tlsSrv.StartTLS()
+	defer tlsSrv.Close()
+	c = newTestClient(tlsSrv.URL)
+	retries = 0
+	c.Logf = func(string, ...interface{}) { retries++ }
+	_, err := c.get(context.Background(), "ipgeo", nil)
+	var authorityErr x509.UnknownAuthorityError
+	if !errors.As(err, &authorityErr) {
+		t.Errorf("got %v, want an unknown authority error", err)
+	}
+	if retries != 0 || hits != 0 {
+		t.Errorf("untrusted certificate: %d retries, %d requests; want none", retries, hits)
+	}
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// SecurityOptions are the query parameters accepted by the IP Security API.
+type SecurityOptions struct {
+	IP       string
+	Excludes []string
+	Fields   []string
+}
+
+func (o SecurityOptions) values() url.Values {
+	query := url.Values{}
+	setString(query, "ip", o.IP)
+	setList(query, "excludes", o.Excludes)
+	setList(query, "fields", o.Fields)
+	return query
+}
+
+// Security looks up threat intelligence for a single IP address.
+func (c *Client) Security(ctx context.Context, opts SecurityOptions) (*Response, error) {
+	return c.get(ctx, "security", opts.values())
+}
+
+// BulkSecurity looks up threat intelligence for several IP addresses in one request.
+func (c *Client) BulkSecurity(ctx context.Context, ips []string, opts SecurityOptions) (*Response, error) {
+	payload := map[string]interface{}{
+		"ips": ips,
+	}
+	return c.post(ctx, "security-bulk", opts.values(), payload)
+}
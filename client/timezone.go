@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// TimezoneOptions are the query parameters accepted by the Timezone API.
+type TimezoneOptions struct {
+	IP        string
+	Tz        string
+	Location  string
+	Latitude  float64
+	Longitude float64
+	IataCode  string
+	IcaoCode  string
+	LoCode    string
+	Language  string
+}
+
+func (o TimezoneOptions) values() url.Values {
+	query := url.Values{}
+	setString(query, "ip", o.IP)
+	setString(query, "iata_code", o.IataCode)
+	setString(query, "icao_code", o.IcaoCode)
+	setString(query, "lo_code", o.LoCode)
+	setString(query, "lang", o.Language)
+	setString(query, "tz", o.Tz)
+	setString(query, "location", o.Location)
+	setFloat(query, "lat", o.Latitude)
+	setFloat(query, "long", o.Longitude)
+	return query
+}
+
+// Timezone looks up timezone information for an IP, place, coordinate or code.
+func (c *Client) Timezone(ctx context.Context, opts TimezoneOptions) (*Response, error) {
+	return c.get(ctx, "timezone", opts.values())
+}
+
+// ConvertTimeOptions are the query parameters accepted by the Time Conversion API.
+type ConvertTimeOptions struct {
+	TimezoneFrom  string
+	TimezoneTo    string
+	LocationFrom  string
+	LocationTo    string
+	LatitudeFrom  float64
+	LongitudeFrom float64
+	LatitudeTo    float64
+	LongitudeTo   float64
+	IataCodeFrom  string
+	IataCodeTo    string
+	IcaoCodeFrom  string
+	IcaoCodeTo    string
+	LoCodeFrom    string
+	LoCodeTo      string
+	Time          string
+}
+
+func (o ConvertTimeOptions) values() url.Values {
+	query := url.Values{}
+	setString(query, "tz_from", o.TimezoneFrom)
+	setString(query, "tz_to", o.TimezoneTo)
+	setString(query, "location_from", o.LocationFrom)
+	setString(query, "location_to", o.LocationTo)
+	setFloat(query, "lat_from", o.LatitudeFrom)
+	setFloat(query, "long_from", o.LongitudeFrom)
+	setFloat(query, "lat_to", o.LatitudeTo)
+	setFloat(query, "long_to", o.LongitudeTo)
+	setString(query, "iata_from", o.IataCodeFrom)
+	setString(query, "iata_to", o.IataCodeTo)
+	setString(query, "icao_from", o.IcaoCodeFrom)
+	setString(query, "icao_to", o.IcaoCodeTo)
+	setString(query, "locode_from", o.LoCodeFrom)
+	setString(query, "locode_to", o.LoCodeTo)
+	setString(query, "time", o.Time)
+	return query
+}
+
+// ConvertTime converts a time between two timezones or locations.
+func (c *Client) ConvertTime(ctx context.Context, opts ConvertTimeOptions) (*Response, error) {
+	return c.get(ctx, "timezone/convert", opts.values())
+}
@@ -0,0 +1,20 @@
+package client
+
+import "context"
+
+// ParseUserAgent extracts device, browser, engine and OS details from a
+// User-Agent string.
+func (c *Client) ParseUserAgent(ctx context.Context, userAgent string) (*Response, error) {
+	payload := map[string]interface{}{
+		"uaString": userAgent,
+	}
+	return c.post(ctx, "user-agent", nil, payload)
+}
+
+// ParseBulkUserAgents parses several User-Agent strings in one request.
+func (c *Client) ParseBulkUserAgents(ctx context.Context, userAgents []string) (*Response, error) {
+	payload := map[string]interface{}{
+		"uaStrings": userAgents,
+	}
+	return c.post(ctx, "user-agent-bulk", nil, payload)
+}
@@ -1,18 +1,12 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
 
+	"github.com/IPGeolocation/cli/v2/client"
 	"github.com/IPGeolocation/cli/v2/internal/common"
-	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var asnFlags common.ASNFlags
@@ -31,71 +25,23 @@ Retrieving ASN information for a specific ASN number ipgeolocation asn --asn 123
 
 Getting peering relationships for an ASN number: ipgeolocation asn --asn 12345 --include=peers
 	`,
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.Load()
-		if err != nil || cfg.ApiKey == "" {
-			fmt.Println("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
-			return
-		}
-
-		baseURL := "https://api.ipgeolocation.io/v3/asn"
-		url := baseURL + "?apiKey=" + cfg.ApiKey
-		if asnFlags.IP != "" {
-			url += "&ip=" + asnFlags.IP
-		}
-
-		if asnFlags.ASN != "" {
-			url += "&asn=" + asnFlags.ASN
-		}
-
-		if len(asnFlags.Include) > 0 {
-			url += "&include=" + strings.Join(asnFlags.Include, ",")
-		}
-
-		if len(asnFlags.Excludes) > 0 {
-			url += "&excludes=" + strings.Join(asnFlags.Excludes, ",")
-		}
-
-		if len(asnFlags.Fields) > 0 {
-			url += "&fields=" + strings.Join(asnFlags.Fields, ",")
-		}
-
-		resp, err := http.Get(url)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient()
 		if err != nil {
-			fmt.Println("Error fetching ASN info:", err)
-			return
+			return err
 		}
-		defer resp.Body.Close()
 
-		body, _ := io.ReadAll(resp.Body)
-
-		if resp.StatusCode != 200 {
-			fmt.Printf("Error: %s\n", string(body))
-			return
-		}
-
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			fmt.Println("Error parsing JSON:", err)
-			return
-		}
-
-		switch asnFlags.Output {
-		case "raw":
-			fmt.Println(string(body))
-		case "table":
-			utils.PrintAsTable(result, 0)
-		case "yaml":
-			yamlData, err := yaml.Marshal(result)
-			if err != nil {
-				fmt.Println("Error converting to YAML:", err)
-				return
-			}
-			fmt.Println(string(yamlData))
-		default:
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(pretty))
+		resp, err := c.ASN(cmd.Context(), client.ASNOptions{
+			IP:       asnFlags.IP,
+			ASN:      asnFlags.ASN,
+			Include:  asnFlags.Include,
+			Excludes: asnFlags.Excludes,
+			Fields:   asnFlags.Fields,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch ASN info: %w", err)
 		}
+		return printResponse(asnFlags.Output, "asn", resp.Body)
 	},
 }
 
@@ -105,7 +51,7 @@ func init() {
 	asnCmd.Flags().StringSliceVar(&asnFlags.Include, "include", []string{}, "To include additional values in the output")
 	asnCmd.Flags().StringSliceVar(&asnFlags.Excludes, "exclude", []string{}, "Fields to exclude from the output")
 	asnCmd.Flags().StringSliceVar(&asnFlags.Fields, "fields", []string{}, "Get Specific Fields to include in the output")
-	asnCmd.Flags().StringVar(&asnFlags.Output, "output", "pretty", "Output format: pretty, raw, table")
+	addOutputFlag(asnCmd, &asnFlags.Output)
 
 	rootCmd.AddCommand(asnCmd)
 }
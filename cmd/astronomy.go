@@ -1,18 +1,12 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	encoding "net/url"
 
+	"github.com/IPGeolocation/cli/v2/client"
 	"github.com/IPGeolocation/cli/v2/internal/common"
-	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var astronomyFlags common.AstronomyFlags
@@ -47,79 +41,25 @@ API Reference: https://ipgeolocation.io/astronomy-api.html
   # YAML output
   ipgeolocation astronomy --ip=1.1.1.1 --output=yaml
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.Load()
-		if err != nil || cfg.ApiKey == "" {
-			fmt.Println("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
-			return
-		}
-
-		baseURL := "https://api.ipgeolocation.io/v3/astronomy"
-		url := baseURL + "?apiKey=" + cfg.ApiKey
-		if astronomyFlags.IP != "" {
-			url += "&ip=" + astronomyFlags.IP
-		}
-
-		if astronomyFlags.Tz != "" {
-			url += "&time_zone=" + astronomyFlags.Tz
-		}
-
-		if astronomyFlags.Language != "" {
-			url += "&lang=" + astronomyFlags.Language
-		}
-
-		if astronomyFlags.Location != "" {
-			url += "&location=" + encoding.QueryEscape(astronomyFlags.Location)
-		}
-
-		if astronomyFlags.Latitude != 0 {
-			url += "&lat=" + fmt.Sprintf("%f", astronomyFlags.Latitude)
-		}
-
-		if astronomyFlags.Longitude != 0 {
-			url += "&long=" + fmt.Sprintf("%f", astronomyFlags.Longitude)
-		}
-
-		if astronomyFlags.Elevation != 0 {
-			url += "&elevation=" + fmt.Sprintf("%f", astronomyFlags.Elevation)
-		}
-
-		resp, err := http.Get(url)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient()
 		if err != nil {
-			fmt.Println("Error fetching astronomy info:", err)
-			return
+			return err
 		}
-		defer resp.Body.Close()
-
-		body, _ := io.ReadAll(resp.Body)
 
-		if resp.StatusCode != 200 {
-			fmt.Printf("Error: %s\n", string(body))
-			return
-		}
-
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			fmt.Println("Error parsing JSON:", err)
-			return
-		}
-
-		switch astronomyFlags.Output {
-		case "raw":
-			fmt.Println(string(body))
-		case "table":
-			utils.PrintAsTable(result, 0)
-		case "yaml":
-			yamlData, err := yaml.Marshal(result)
-			if err != nil {
-				fmt.Println("Error converting to YAML:", err)
-				return
-			}
-			fmt.Println(string(yamlData))
-		default:
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(pretty))
+		resp, err := c.Astronomy(cmd.Context(), client.AstronomyOptions{
+			IP:        astronomyFlags.IP,
+			Location:  astronomyFlags.Location,
+			Latitude:  astronomyFlags.Latitude,
+			Longitude: astronomyFlags.Longitude,
+			Language:  astronomyFlags.Language,
+			Tz:        astronomyFlags.Tz,
+			Elevation: astronomyFlags.Elevation,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch astronomy info: %w", err)
 		}
+		return printResponse(astronomyFlags.Output, "astronomy", resp.Body)
 	},
 }
 
@@ -132,7 +72,7 @@ func init() {
 	astronomyCmd.Flags().Float64Var(&astronomyFlags.Longitude, "longitude", 0, "Longitude (e.g. -122.4194)")
 	astronomyCmd.Flags().StringVar(&astronomyFlags.Language, "lang", "", "Language code (e.g. en)")
 	astronomyCmd.Flags().Float64Var(&astronomyFlags.Elevation, "elevation", 0, "Elevation (e.g. 1000)")
-	astronomyCmd.Flags().StringVar(&astronomyFlags.Output, "output", "pretty", "Output format: pretty, raw, table")
+	addOutputFlag(astronomyCmd, &astronomyFlags.Output)
 
 	rootCmd.AddCommand(astronomyCmd)
 }
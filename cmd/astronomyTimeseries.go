@@ -1,18 +1,12 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	encoding "net/url"
 
+	"github.com/IPGeolocation/cli/v2/client"
 	"github.com/IPGeolocation/cli/v2/internal/common"
-	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var astronomyTimeseriesFlags common.AstronomyTimeSeriesFlags
@@ -36,85 +30,29 @@ Examples:
 
 
   `,
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.Load()
-		if err != nil || cfg.ApiKey == "" {
-			fmt.Println("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
-			return
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient()
+		if err != nil {
+			return err
 		}
 
-		baseURL := "https://api.ipgeolocation.io/v3/astronomy/timeSeries"
-		url := baseURL + "?apiKey=" + cfg.ApiKey
-
 		if astronomyTimeseriesFlags.DateStart == "" || astronomyTimeseriesFlags.DateEnd == "" {
-			fmt.Println("Please provide both start and end dates.")
-			return
-		}
-
-		if astronomyTimeseriesFlags.DateStart != "" {
-			url += "&dateStart=" + astronomyTimeseriesFlags.DateStart
-		}
-
-		if astronomyTimeseriesFlags.DateEnd != "" {
-			url += "&dateEnd=" + astronomyTimeseriesFlags.DateEnd
-		}
-
-		if astronomyTimeseriesFlags.IP != "" {
-			url += "&ip=" + astronomyTimeseriesFlags.IP
-		}
-
-		if astronomyTimeseriesFlags.Language != "" {
-			url += "&lang=" + astronomyTimeseriesFlags.Language
-		}
-
-		if astronomyTimeseriesFlags.Location != "" {
-			url += "&location=" + encoding.QueryEscape(astronomyTimeseriesFlags.Location)
+			return usageErrorf("please provide both start and end dates")
 		}
 
-		if astronomyTimeseriesFlags.Latitude != 0 {
-			url += "&lat=" + fmt.Sprintf("%f", astronomyTimeseriesFlags.Latitude)
-		}
-
-		if astronomyTimeseriesFlags.Longitude != 0 {
-			url += "&long=" + fmt.Sprintf("%f", astronomyTimeseriesFlags.Longitude)
-		}
-
-		resp, err := http.Get(url)
+		resp, err := c.AstronomyTimeSeries(cmd.Context(), client.AstronomyTimeSeriesOptions{
+			IP:        astronomyTimeseriesFlags.IP,
+			Location:  astronomyTimeseriesFlags.Location,
+			Latitude:  astronomyTimeseriesFlags.Latitude,
+			Longitude: astronomyTimeseriesFlags.Longitude,
+			Language:  astronomyTimeseriesFlags.Language,
+			DateStart: astronomyTimeseriesFlags.DateStart,
+			DateEnd:   astronomyTimeseriesFlags.DateEnd,
+		})
 		if err != nil {
-			fmt.Println("Error fetching astronomy time-series info:", err)
-			return
-		}
-		defer resp.Body.Close()
-
-		body, _ := io.ReadAll(resp.Body)
-
-		if resp.StatusCode != 200 {
-			fmt.Printf("Error: %s\n", string(body))
-			return
-		}
-
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			fmt.Println("Error parsing JSON:", err)
-			return
-		}
-
-		switch astronomyTimeseriesFlags.Output {
-		case "raw":
-			fmt.Println(string(body))
-		case "table":
-			utils.PrintAsTable(result, 0)
-		case "yaml":
-			yamlData, err := yaml.Marshal(result)
-			if err != nil {
-				fmt.Println("Error converting to YAML:", err)
-				return
-			}
-			fmt.Println(string(yamlData))
-		default:
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(pretty))
+			return fmt.Errorf("failed to fetch astronomy time-series info: %w", err)
 		}
+		return printResponse(astronomyTimeseriesFlags.Output, "astronomy/timeSeries", resp.Body)
 	},
 }
 
@@ -127,7 +65,7 @@ func init() {
 	astronomyTimeseriesCmd.Flags().Float64Var(&astronomyTimeseriesFlags.Latitude, "latitude", 0, "Latitude (e.g. 37.7749)")
 	astronomyTimeseriesCmd.Flags().Float64Var(&astronomyTimeseriesFlags.Longitude, "longitude", 0, "Longitude (e.g. -122.4194)")
 	astronomyTimeseriesCmd.Flags().StringVar(&astronomyTimeseriesFlags.Language, "lang", "", "Language code (e.g. en)")
-	astronomyTimeseriesCmd.Flags().StringVar(&astronomyTimeseriesFlags.Output, "output", "pretty", "Output format: pretty, raw, table, yaml")
+	addOutputFlag(astronomyTimeseriesCmd, &astronomyTimeseriesFlags.Output)
 
 	rootCmd.AddCommand(astronomyTimeseriesCmd)
 }
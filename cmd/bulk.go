@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/IPGeolocation/cli/v2/client"
+)
+
+// bulkFetchFunc sends one bulk request for a batch of items.
+type bulkFetchFunc func(ctx context.Context, batch []string) (*client.Response, error)
+
+// fetchInBatches sends items in batches of size (all at once when size is
+// not positive) and concatenates the arrays returned for each batch.
+//
+// When ctx is cancelled or a batch fails, the results gathered so far are
+// returned together with the error so that callers can still save them.
+// body is the raw response for a single batch and the re-encoded results
+// otherwise.
+func fetchInBatches(ctx context.Context, items []string, size int, fetch bulkFetchFunc) (body []byte, results []interface{}, err error) {
+	if size <= 0 || size > len(items) {
+		size = len(items)
+	}
+
+	results = []interface{}{}
+	batches := 0
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, results, err
+		}
+
+		resp, err := fetch(ctx, items[start:end])
+		if err != nil {
+			return nil, results, err
+		}
+
+		decoded, err := client.DecodeJSON(resp.Body)
+		if err != nil {
+			return nil, results, fmt.Errorf("invalid JSON: %w", err)
+		}
+		batch, ok := decoded.([]interface{})
+		if !ok {
+			return nil, results, fmt.Errorf("invalid JSON: expected an array of results")
+		}
+		results = append(results, batch...)
+		body = resp.Body
+		batches++
+		verbosef("batch %d done: %d of %d items", batches, end, len(items))
+	}
+
+	if batches > 1 {
+		body, _ = json.Marshal(results)
+	}
+	return body, results, nil
+}
+
+// saveOutputFile writes result as indented JSON to name + ".json".
+func saveOutputFile(name string, result interface{}) (string, error) {
+	path := name + ".json"
+	pretty, _ := json.MarshalIndent(result, "", "  ")
+	return path, os.WriteFile(path, pretty, 0644)
+}
+
+// savePartialResults flushes whatever a failed or interrupted bulk command
+// collected to its --output-file, if one was requested.
+func savePartialResults(outputFile string, results []interface{}, total int) {
+	if outputFile == "" || len(results) == 0 {
+		return
+	}
+	path, err := saveOutputFile(outputFile, results)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error writing JSON to file:", err)
+		return
+	}
+	fmt.Fprintf(stderr, "Partial results (%d of %d) saved to file: %s\n", len(results), total, path)
+}
@@ -2,17 +2,15 @@ package cmd
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/IPGeolocation/cli/v2/client"
 	"github.com/IPGeolocation/cli/v2/internal/common"
-	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var bulkSecurityFlags common.BulkIPSecurityFlags
@@ -29,19 +27,17 @@ Example Use Cases:
 Retrieving security information for a list of IP addresses: ipgeolocation bulk-ip-security --ips "8.8.8.8,8.8.4.4"
 
 Retrieving security information for IP addresses from a file: ipgeolocation bulk-ip-security --file ips.txt`,
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.Load()
-		if err != nil || cfg.ApiKey == "" {
-			fmt.Println("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
-			return
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient()
+		if err != nil {
+			return err
 		}
 
 		// If --file is provided, read IPs from file
 		if bulkSecurityFlags.File != "" {
 			file, err := os.Open(bulkSecurityFlags.File)
 			if err != nil {
-				fmt.Println("Error opening file:", err)
-				return
+				return fmt.Errorf("failed to open file: %w", err)
 			}
 			defer file.Close()
 
@@ -53,68 +49,36 @@ Retrieving security information for IP addresses from a file: ipgeolocation bulk
 				}
 			}
 			if err := scanner.Err(); err != nil {
-				fmt.Println("Error reading file:", err)
-				return
+				return fmt.Errorf("failed to read file: %w", err)
 			}
 		}
 
 		if len(bulkSecurityFlags.IPs) == 0 {
-			fmt.Println("Please provide at least one IP address using --ips or --file.")
-			return
-		}
-
-		baseURL := "https://api.ipgeolocation.io/v3/security-bulk"
-		url := baseURL + "?apiKey=" + cfg.ApiKey
-
-		if len(bulkSecurityFlags.Excludes) > 0 {
-			url += "&excludes=" + strings.Join(bulkSecurityFlags.Excludes, ",")
-		}
-
-		if len(bulkSecurityFlags.Fields) > 0 {
-			url += "&fields=" + strings.Join(bulkSecurityFlags.Fields, ",")
+			return usageErrorf("please provide at least one IP address using --ips or --file")
 		}
 
-		payload := map[string]interface{}{
-			"ips": bulkSecurityFlags.IPs,
-		}
-
-		body, err := utils.PostJSON(url, payload, map[string]string{"Content-Type": "application/json"})
+		body, result, err := fetchInBatches(cmd.Context(), bulkSecurityFlags.IPs, bulkSecurityFlags.BatchSize, func(ctx context.Context, batch []string) (*client.Response, error) {
+			return c.BulkSecurity(ctx, batch, client.SecurityOptions{
+				Excludes: bulkSecurityFlags.Excludes,
+				Fields:   bulkSecurityFlags.Fields,
+			})
+		})
 		if err != nil {
-			fmt.Println("Error fetching Bulk IP Security info:", err)
-			return
-		}
-
-		var result interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			fmt.Println("Invalid JSON:", err)
-			return
+			savePartialResults(bulkSecurityFlags.OutputFile, result, len(bulkSecurityFlags.IPs))
+			return fmt.Errorf("failed to fetch Bulk IP Security info: %w", err)
 		}
 
-		switch bulkSecurityFlags.Output {
-		case "raw":
-			fmt.Println(string(body))
-		case "table":
-			utils.PrintAsTable(result, 0)
-		case "yaml":
-			yamlData, err := yaml.Marshal(result)
-			if err != nil {
-				fmt.Println("Error converting to YAML:", err)
-				return
-			}
-			fmt.Println(string(yamlData))
-		default:
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(pretty))
+		if err := printResult(bulkSecurityFlags.Output, "security-bulk", body, result); err != nil {
+			return err
 		}
 		if bulkSecurityFlags.OutputFile != "" {
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			err := os.WriteFile(bulkSecurityFlags.OutputFile+".json", pretty, 0644)
+			path, err := saveOutputFile(bulkSecurityFlags.OutputFile, result)
 			if err != nil {
-				fmt.Println("Error writing JSON to file:", err)
-				return
+				return fmt.Errorf("failed to write JSON to file: %w", err)
 			}
-			fmt.Println("Output saved to file:", bulkSecurityFlags.OutputFile+".json")
+			fmt.Println("Output saved to file:", path)
 		}
+		return nil
 	},
 }
 
@@ -122,9 +86,10 @@ func init() {
 	bulkIpSecurityCmd.Flags().StringSliceVar(&bulkSecurityFlags.IPs, "ips", []string{}, "IPs")
 	bulkIpSecurityCmd.Flags().StringSliceVar(&bulkSecurityFlags.Excludes, "exclude", []string{}, "Fields to exclude from the output")
 	bulkIpSecurityCmd.Flags().StringSliceVar(&bulkSecurityFlags.Fields, "fields", []string{}, "Get Specific Fields to include in the output")
-	bulkIpSecurityCmd.Flags().StringVar(&bulkSecurityFlags.Output, "output", "pretty", "Output format: pretty, raw, table")
+	addOutputFlag(bulkIpSecurityCmd, &bulkSecurityFlags.Output)
 	bulkIpSecurityCmd.Flags().StringVar(&bulkSecurityFlags.File, "file", "", "Path to a text file containing IPs (one per line)")
 	bulkIpSecurityCmd.Flags().StringVar(&bulkSecurityFlags.OutputFile, "output-file", "", "Save output to a file (JSON only)")
+	bulkIpSecurityCmd.Flags().IntVar(&bulkSecurityFlags.BatchSize, "batch-size", 0, "Send IPs in batches of this size (0 sends all in one request)")
 
 	rootCmd.AddCommand(bulkIpSecurityCmd)
 }
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/IPGeolocation/cli/v2/internal/cache"
+	"github.com/IPGeolocation/cli/v2/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clean the response cache",
+	Long: `The 'cache' command manages the on-disk response cache.
+
+Successful responses are cached under the user cache directory (e.g. ~/.cache/ipgeolocation on
+Linux), keyed on the endpoint and its normalized parameters but not on the API key, so repeated
+lookups do not spend credits. Each endpoint has its own TTL: a week for asn and abuse, a day for
+ipgeo, and a minute for timezone, whose responses hold the current time.
+
+Use the global --refresh flag to skip cached responses for one command, and --no-cache to bypass
+the cache entirely. TTLs, the directory and whether the cache is used at all can be set in the
+config file, e.g. 'ipgeolocation config set cache.ttl.ipgeo 1h'.
+
+Examples:
+
+  ipgeolocation cache stats
+  ipgeolocation cache prune
+  ipgeolocation cache clear`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show what the cache holds",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := loadCacheStore()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		stats, err := store.Stats(now)
+		if err != nil {
+			return fmt.Errorf("failed to read cache: %w", err)
+		}
+
+		fmt.Println("📁 Cache directory:", store.Dir)
+		fmt.Printf("🗂  Entries: %d (%d expired), %s\n", stats.Entries, stats.Expired, formatBytes(stats.Bytes))
+		if stats.Entries == 0 {
+			return nil
+		}
+		fmt.Printf("🕒 Oldest: %s ago, newest: %s ago\n", now.Sub(stats.Oldest).Round(time.Second), now.Sub(stats.Newest).Round(time.Second))
+
+		endpoints := make([]string, 0, len(stats.ByEndpoint))
+		for endpoint := range stats.ByEndpoint {
+			endpoints = append(endpoints, endpoint)
+		}
+		sort.Strings(endpoints)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ENDPOINT\tENTRIES")
+		for _, endpoint := range endpoints {
+			fmt.Fprintf(w, "%s\t%d\n", endpoint, stats.ByEndpoint[endpoint])
+		}
+		return w.Flush()
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired cache entries",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := loadCacheStore()
+		if err != nil {
+			return err
+		}
+		removed, err := store.Prune(time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to prune cache: %w", err)
+		}
+		fmt.Printf("✅ Removed %d expired entries.\n", removed)
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cache entries",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := loadCacheStore()
+		if err != nil {
+			return err
+		}
+		removed, err := store.Clear()
+		if err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+		fmt.Printf("✅ Removed %d entries.\n", removed)
+		return nil
+	},
+}
+
+func loadCacheStore() (*cache.Store, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cacheStore(cfg)
+}
+
+// formatBytes renders a size in B, KB or MB.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+func init() {
+	// Cache settings under cache.ttl.<endpoint> for 'config get/set'.
+	for endpoint := range cache.DefaultTTLs {
+		endpoint := endpoint
+		configSettings["cache.ttl."+endpoint] = configSetting{
+			get: func(cfg *config.Config) string {
+				if cfg.Cache == nil {
+					return ""
+				}
+				return cfg.Cache.TTL[endpoint]
+			},
+			set: func(cfg *config.Config, value string) error {
+				if cfg.Cache == nil {
+					cfg.Cache = &config.CacheSettings{}
+				}
+				if value == "" {
+					delete(cfg.Cache.TTL, endpoint)
+					return nil
+				}
+				if cfg.Cache.TTL == nil {
+					cfg.Cache.TTL = map[string]string{}
+				}
+				cfg.Cache.TTL[endpoint] = value
+				return nil
+			},
+			validate: validateDuration,
+		}
+	}
+
+	cacheCmd.AddCommand(cacheStatsCmd, cachePruneCmd, cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
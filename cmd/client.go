@@ -0,0 +1,669 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/IPGeolocation/cli/v2/client"
+	"github.com/IPGeolocation/cli/v2/internal/cache"
+	"github.com/IPGeolocation/cli/v2/internal/config"
+	"github.com/IPGeolocation/cli/v2/internal/fixture"
+	"github.com/IPGeolocation/cli/v2/internal/ratelimit"
+	"github.com/IPGeolocation/cli/v2/internal/redact"
+	"github.com/IPGeolocation/cli/v2/internal/tracing"
+	"github.com/IPGeolocation/cli/v2/internal/usage"
+
+	"github.com/spf13/cobra"
+)
+
+// Environment variables read by the CLI.
+const (
+	envAPIKey  = "IPGEOLOCATION_API_KEY"
+	envAPIURL  = "IPGEOLOCATION_API_URL"
+	envProfile = "IPGEOLOCATION_PROFILE"
+
+	envPassphrase    = "IPGEOLOCATION_PASSPHRASE"
+	envNewPassphrase = "IPGEOLOCATION_NEW_PASSPHRASE"
+)
+
+// Places the active API key can come from, in order of precedence.
+const (
+	keySourceFlag   = "--api-key flag"
+	keySourceEnv    = envAPIKey + " environment variable"
+	keySourceConfig = "config file"
+)
+
+// newClient loads the saved configuration and returns an API client for it.
+func newClient() (*client.Client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := activeProfile(cfg); err != nil {
+		return nil, err
+	}
+
+	offline, err := resolveOffline(cfg)
+	if err != nil {
+		return nil, err
+	}
+	replay, err := replayTransport(offline)
+	if err != nil {
+		return nil, err
+	}
+
+	// Offline and replayed lookups never reach the API, so they need no key.
+	apiKey := ""
+	if !offline && replay == nil {
+		if apiKey, _, err = resolveAPIKey(cfg); err != nil {
+			return nil, err
+		}
+		if apiKey == "" {
+			return nil, errNoAPIKey
+		}
+	}
+
+	baseURL := resolveAPIURL(cfg)
+	if err := client.ValidateBaseURL(baseURL); err != nil {
+		return nil, &usageError{msg: err.Error()}
+	}
+
+	retry, err := resolveRetryPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := resolveTimeout(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	limited, err := rateTransport(cfg, traceTransport(transport))
+	if err != nil {
+		return nil, err
+	}
+	cached, err := cacheTransport(cfg, baseURL, offline, meterTransport(cfg, limited))
+	if err != nil {
+		return nil, err
+	}
+	var roundTripper http.RoundTripper = cached
+	switch {
+	case replay != nil:
+		roundTripper = replay
+	case globalFlags.RecordDir != "":
+		roundTripper = &fixture.Recorder{Base: cached, Dir: globalFlags.RecordDir, Scrub: redact.String}
+	}
+
+	c := client.New(apiKey)
+	c.BaseURL = baseURL
+	c.HTTPClient = &http.Client{Timeout: timeout, Transport: roundTripper}
+	c.Retry = retry
+	if offline || replay != nil {
+		// Retrying would only get the same answer again.
+		c.Retry.MaxRetries = 0
+	}
+	c.Logf = verbosef
+	return c, nil
+}
+
+// loadConfig reads the config file, treating a missing file as empty so
+// that the CLI can run purely from flags and environment variables.
+func loadConfig() (config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return cfg, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, nil
+}
+
+// profileName returns the profile selected with --profile,
+// IPGEOLOCATION_PROFILE or the config file's current_profile, in that order.
+func profileName(cfg config.Config) string {
+	if globalFlags.Profile != "" {
+		return globalFlags.Profile
+	}
+	if v := os.Getenv(envProfile); v != "" {
+		return v
+	}
+	return cfg.CurrentProfile
+}
+
+// activeProfile returns the selected profile, or nil when none is selected.
+func activeProfile(cfg config.Config) (string, *config.Profile, error) {
+	name := profileName(cfg)
+	if name == "" {
+		return "", nil, nil
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return name, nil, usageErrorf("profile %q not found. Run: ipgeolocation config profiles list", name)
+	}
+	return name, p, nil
+}
+
+// resolveAPIKey picks the API key from the --api-key flag, the
+// IPGEOLOCATION_API_KEY environment variable, the active profile or the
+// config file, in that order, and reports where it came from. The key is
+// registered with package redact so that diagnostics never show it.
+func resolveAPIKey(cfg config.Config) (key, source string, err error) {
+	defer func() { redact.Add(key) }()
+
+	if globalFlags.APIKey != "" {
+		return globalFlags.APIKey, keySourceFlag, nil
+	}
+	if v := os.Getenv(envAPIKey); v != "" {
+		return v, keySourceEnv, nil
+	}
+
+	if name, p, _ := activeProfile(cfg); p != nil {
+		store, err := secretStore(&cfg, name)
+		if err != nil {
+			return "", "", err
+		}
+		key, err := readKey(store)
+		if err != nil {
+			return "", "", err
+		}
+		if key != "" {
+			return key, fmt.Sprintf("profile %q, %s", name, store), nil
+		}
+	}
+
+	store, _ := secretStore(&cfg, "")
+	key, err = readKey(store)
+	if err != nil {
+		return "", "", err
+	}
+	if key != "" {
+		return key, keySourceConfig, nil
+	}
+	return "", "", nil
+}
+
+// maskKey hides all but the last five characters of an API key.
+func maskKey(key string) string {
+	masked := "********"
+	if len(key) > 5 {
+		return masked + key[len(key)-5:]
+	}
+	return masked + key
+}
+
+// resolveAPIURL picks the API base URL from the --api-url flag, the
+// IPGEOLOCATION_API_URL environment variable, the active profile or the
+// config file, in that order.
+func resolveAPIURL(cfg config.Config) string {
+	if globalFlags.APIURL != "" {
+		return globalFlags.APIURL
+	}
+	if v := os.Getenv(envAPIURL); v != "" {
+		return v
+	}
+	if _, p, _ := activeProfile(cfg); p != nil && p.ApiURL != "" {
+		return p.ApiURL
+	}
+	if cfg.ApiURL != "" {
+		return cfg.ApiURL
+	}
+	return client.DefaultBaseURL
+}
+
+// resolveRetryPolicy uses --retries and --retry-max-wait when given on the
+// command line and falls back to the config file, then to the defaults.
+func resolveRetryPolicy(cfg config.Config) (client.RetryPolicy, error) {
+	policy := client.RetryPolicy{
+		MaxRetries: globalFlags.Retries,
+		MaxWait:    globalFlags.RetryMaxWait,
+	}
+
+	flags := rootCmd.PersistentFlags()
+	if !flags.Changed("retries") && cfg.Retries != nil {
+		policy.MaxRetries = *cfg.Retries
+	}
+	if !flags.Changed("retry-max-wait") && cfg.RetryMaxWait != "" {
+		wait, err := time.ParseDuration(cfg.RetryMaxWait)
+		if err != nil {
+			return policy, fmt.Errorf("invalid retry_max_wait in config: %w", err)
+		}
+		policy.MaxWait = wait
+	}
+
+	if policy.MaxRetries < 0 {
+		return policy, usageErrorf("--retries must not be negative")
+	}
+	return policy, nil
+}
+
+// resolveTimeout uses --timeout when given on the command line and falls
+// back to the config file, then to the default.
+func resolveTimeout(cfg config.Config) (time.Duration, error) {
+	timeout := globalFlags.Timeout
+	if !rootCmd.PersistentFlags().Changed("timeout") && cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timeout in config: %w", err)
+		}
+		timeout = d
+	}
+	if timeout < 0 {
+		return 0, usageErrorf("--timeout must not be negative")
+	}
+	return timeout, nil
+}
+
+// resolveRateLimit merges the rate limit settings of the config file, the
+// active profile and the command line, later ones taking precedence. The
+// burst defaults to the rate rounded up.
+func resolveRateLimit(cfg config.Config) (config.RateLimit, error) {
+	limit := cfg.RateLimit
+	if _, p, _ := activeProfile(cfg); p != nil {
+		if p.Rate != 0 {
+			limit.Rate = p.Rate
+		}
+		if p.Burst != 0 {
+			limit.Burst = p.Burst
+		}
+	}
+
+	flags := rootCmd.PersistentFlags()
+	if flags.Changed("rate") {
+		limit.Rate = globalFlags.Rate
+	}
+	if flags.Changed("burst") {
+		limit.Burst = globalFlags.Burst
+	}
+
+	if limit.Rate < 0 {
+		return limit, usageErrorf("--rate must not be negative")
+	}
+	if limit.Burst < 0 {
+		return limit, usageErrorf("--burst must not be negative")
+	}
+	if limit.Burst == 0 {
+		limit.Burst = int(math.Ceil(limit.Rate))
+	}
+	return limit, nil
+}
+
+// rateTransport wraps base in a rate limiter when a rate is set. The
+// limiter's bucket is kept in the cache directory, one per profile, so that
+// concurrent invocations share it. When the directory cannot be located, or
+// the bucket file in it cannot be created or locked, the limit applies to
+// this process only.
+func rateTransport(cfg config.Config, base http.RoundTripper) (http.RoundTripper, error) {
+	limit, err := resolveRateLimit(cfg)
+	if err != nil || limit.Rate == 0 {
+		return base, err
+	}
+
+	limiter := ratelimit.New(limit.Rate, limit.Burst)
+	limiter.Logf = verbosef
+	if store, err := cacheStore(cfg); err == nil {
+		name := profileName(cfg)
+		if name == "" {
+			name = "default"
+		}
+		limiter.File = filepath.Join(store.Dir, "ratelimit", name+".json")
+	} else {
+		verbosef("rate limit: applies to this process only: %v", err)
+	}
+	return &ratelimit.Transport{Base: base, Limiter: limiter, Logf: verbosef}, nil
+}
+
+// newTransport builds the transport shared by all requests of the
+// invocation from the network settings of resolveNetwork.
+func newTransport(cfg config.Config) (http.RoundTripper, error) {
+	settings, err := resolveNetwork(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if settings.InsecureSkipVerify {
+		fmt.Fprintln(stderr, "⚠️  WARNING: TLS certificate verification is disabled (insecure_skip_verify). Responses can be forged and your API key intercepted.")
+	}
+	if settings.Proxy != "" {
+		u, err := client.ParseProxyURL(settings.Proxy)
+		if err != nil {
+			return nil, &usageError{msg: err.Error()}
+		}
+		if password, ok := u.User.Password(); ok {
+			redact.Add(password)
+		}
+	}
+
+	t, err := client.NewTransport(settings)
+	if err != nil {
+		return nil, &usageError{msg: err.Error()}
+	}
+	return t, nil
+}
+
+// resolveNetwork merges the proxy and TLS settings of the config file, the
+// active profile and the command line, later ones taking precedence.
+func resolveNetwork(cfg config.Config) (client.TransportConfig, error) {
+	n := cfg.Network
+	if _, p, _ := activeProfile(cfg); p != nil {
+		n = mergeNetwork(n, p.Network)
+	}
+
+	flags := rootCmd.PersistentFlags()
+	n = mergeNetwork(n, config.Network{
+		Proxy:      globalFlags.Proxy,
+		CACert:     globalFlags.CACert,
+		ClientCert: globalFlags.ClientCert,
+		ClientKey:  globalFlags.ClientKey,
+	})
+	if flags.Changed("insecure-skip-verify") {
+		n.InsecureSkipVerify = globalFlags.InsecureSkipVerify
+	}
+
+	if (n.ClientCert == "") != (n.ClientKey == "") {
+		return client.TransportConfig{}, usageErrorf("--client-cert and --client-key must be given together")
+	}
+	return client.TransportConfig{
+		Proxy:              n.Proxy,
+		CACertFile:         n.CACert,
+		ClientCertFile:     n.ClientCert,
+		ClientKeyFile:      n.ClientKey,
+		InsecureSkipVerify: n.InsecureSkipVerify,
+	}, nil
+}
+
+// mergeNetwork returns base with the non-empty settings of override.
+func mergeNetwork(base, override config.Network) config.Network {
+	if override.Proxy != "" {
+		base.Proxy = override.Proxy
+	}
+	if override.CACert != "" {
+		base.CACert = override.CACert
+	}
+	if override.ClientCert != "" || override.ClientKey != "" {
+		base.ClientCert = override.ClientCert
+		base.ClientKey = override.ClientKey
+	}
+	if override.InsecureSkipVerify {
+		base.InsecureSkipVerify = true
+	}
+	return base
+}
+
+// applyConfigDefaults fills in the flags of cmd that were not given on the
+// command line from the config file. The active profile's lang and output
+// take precedence over defaults.<command>, which take precedence over
+// defaults.global. Problems with the config are left for newClient to report.
+func applyConfigDefaults(cmd *cobra.Command) {
+	if !acceptsDefaults(cmd) {
+		return
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return
+	}
+
+	// Merge first and set each flag once: slice flags append on every Set
+	// after the first.
+	values := map[string]string{}
+	for name, value := range cfg.Defaults[config.DefaultsGlobal] {
+		values[name] = value
+	}
+	for name, value := range cfg.Defaults[cmd.Name()] {
+		values[name] = value
+	}
+	if _, p, err := activeProfile(cfg); err == nil && p != nil {
+		if p.Language != "" {
+			values["lang"] = p.Language
+		}
+		if p.Output != "" {
+			values["output"] = p.Output
+		}
+	}
+
+	for name, value := range values {
+		setFlagDefault(cmd, name, value)
+	}
+}
+
+// acceptsDefaults reports whether cmd takes flag defaults from the config
+// file. Only top-level commands do; the config commands never do, so that a
+// default cannot turn into a write.
+func acceptsDefaults(cmd *cobra.Command) bool {
+	if !cmd.HasParent() || cmd.Parent().HasParent() {
+		return false
+	}
+	switch cmd.Name() {
+	case "config", "help", "completion":
+		return false
+	}
+	return true
+}
+
+// setFlagDefault sets a flag that was not given on the command line,
+// leaving it marked as unchanged.
+func setFlagDefault(cmd *cobra.Command, name, value string) {
+	f := cmd.LocalNonPersistentFlags().Lookup(name)
+	if f == nil || f.Changed || value == "" {
+		return
+	}
+	if err := f.Value.Set(value); err != nil {
+		verbosef("ignoring config default for --%s: %v", name, err)
+	}
+}
+
+func init() {
+	config.OnMigrate = func(from, to int, backup string) {
+		fmt.Fprintf(stderr, "ℹ️  Upgraded config file from version %d to %d; the original was saved to %s\n", from, to, backup)
+	}
+}
+
+// cacheStore returns the response cache configured in cfg.
+func cacheStore(cfg config.Config) (*cache.Store, error) {
+	if cfg.Cache != nil && cfg.Cache.Dir != "" {
+		return &cache.Store{Dir: cfg.Cache.Dir}, nil
+	}
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return &cache.Store{Dir: dir}, nil
+}
+
+// cacheTTLs returns the default TTLs with those of the config file applied.
+func cacheTTLs(cfg config.Config) (map[string]time.Duration, error) {
+	ttls := map[string]time.Duration{}
+	for endpoint, ttl := range cache.DefaultTTLs {
+		ttls[endpoint] = ttl
+	}
+	if cfg.Cache == nil {
+		return ttls, nil
+	}
+	for endpoint, value := range cfg.Cache.TTL {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache TTL for %s in config: %w", endpoint, err)
+		}
+		ttls[endpoint] = ttl
+	}
+	return ttls, nil
+}
+
+// resolveOffline reports whether commands must answer from the cache only,
+// as asked with --offline or by the active profile.
+func resolveOffline(cfg config.Config) (bool, error) {
+	offline := globalFlags.Offline
+	if !rootCmd.PersistentFlags().Changed("offline") {
+		if _, p, _ := activeProfile(cfg); p != nil {
+			offline = p.Offline
+		}
+	}
+	if !offline {
+		return false, nil
+	}
+
+	switch {
+	case globalFlags.NoCache:
+		return false, usageErrorf("--offline cannot be combined with --no-cache")
+	case globalFlags.Refresh:
+		return false, usageErrorf("--offline cannot be combined with --refresh")
+	case cfg.Cache != nil && cfg.Cache.Disabled:
+		return false, usageErrorf("offline mode needs the response cache, which is disabled in the config file")
+	}
+	return true, nil
+}
+
+// replayTransport returns the transport replaying --replay recordings, or
+// nil when not replaying.
+func replayTransport(offline bool) (http.RoundTripper, error) {
+	if globalFlags.ReplayDir == "" {
+		if rootCmd.PersistentFlags().Changed("replay-match") {
+			return nil, usageErrorf("--replay-match needs --replay")
+		}
+		return nil, nil
+	}
+	switch {
+	case globalFlags.RecordDir != "":
+		return nil, usageErrorf("--record and --replay cannot be combined")
+	case offline:
+		return nil, usageErrorf("--replay cannot be combined with offline mode")
+	}
+	if globalFlags.ReplayMatch != fixture.MatchStrict && globalFlags.ReplayMatch != fixture.MatchLenient {
+		return nil, usageErrorf("--replay-match must be %s or %s", fixture.MatchStrict, fixture.MatchLenient)
+	}
+
+	replayer, err := fixture.NewReplayer(globalFlags.ReplayDir, globalFlags.ReplayMatch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recordings: %w", err)
+	}
+	return replayer, nil
+}
+
+// cacheTransport wraps base with the response cache unless it is turned
+// off with --no-cache or in the config file. Cache hits never reach base,
+// so they are not traced. In offline mode base is never used.
+func cacheTransport(cfg config.Config, baseURL string, offline bool, base http.RoundTripper) (http.RoundTripper, error) {
+	if !offline && (globalFlags.NoCache || (cfg.Cache != nil && cfg.Cache.Disabled)) {
+		return base, nil
+	}
+	store, err := cacheStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ttls, err := cacheTTLs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &cache.Transport{
+		Base:    base,
+		Store:   store,
+		BaseURL: baseURL,
+		TTLs:    ttls,
+		Refresh: globalFlags.Refresh,
+		Offline: offline,
+		OnHit:   offlineMarker(offline),
+		Logf:    verbosef,
+	}, nil
+}
+
+// offlineHits describes the responses served from the cache in offline
+// mode, so that printResult can mark the output as cached.
+var offlineHits struct {
+	count   int
+	age     time.Duration // of the oldest response
+	expired bool          // whether any response is past its TTL
+}
+
+// offlineMarker returns a cache hook telling the user, in offline mode,
+// that a response comes from the cache and how old it is. It also records
+// the hit in offlineHits.
+func offlineMarker(offline bool) func(endpoint string, age time.Duration, expired bool) {
+	if !offline {
+		return nil
+	}
+	return func(endpoint string, age time.Duration, expired bool) {
+		offlineHits.count++
+		if age > offlineHits.age {
+			offlineHits.age = age
+		}
+		offlineHits.expired = offlineHits.expired || expired
+
+		note := ""
+		if expired {
+			note = ", past its TTL"
+		}
+		fmt.Fprintf(stderr, "📦 Offline: cached %s response from %s ago%s\n", endpoint, age.Round(time.Second), note)
+	}
+}
+
+// harLog records the HTTP traffic when --har is set; Execute writes it out.
+var harLog *tracing.HAR
+
+// traceTransport wraps base to log HTTP traffic for --verbose and --debug
+// and to record it for --har.
+func traceTransport(base http.RoundTripper) http.RoundTripper {
+	verbose := globalFlags.Verbose || globalFlags.Debug
+	if !verbose && globalFlags.HARFile == "" {
+		return base
+	}
+
+	t := &tracing.Transport{
+		Base:      base,
+		Bodies:    globalFlags.Debug,
+		BodyLimit: globalFlags.DebugBodyLimit,
+	}
+	if verbose {
+		t.Logf = verbosef
+	}
+	if globalFlags.HARFile != "" {
+		if harLog == nil {
+			harLog = tracing.NewHAR(rootCmd.Name(), rootCmd.Version)
+			harLog.Scrub = redact.String
+		}
+		t.HAR = harLog
+	}
+	return t
+}
+
+// usageMeter counts the requests that reach the API and the credits they
+// cost; Execute adds them to the usage ledger. usageProfile is the profile
+// they are booked to.
+var (
+	usageMeter   *usage.Meter
+	usageProfile string
+)
+
+// meterTransport wraps base to meter the requests sent through it. It sits
+// below the cache, so cached answers are not counted.
+func meterTransport(cfg config.Config, base http.RoundTripper) http.RoundTripper {
+	usageMeter = &usage.Meter{Base: base}
+	usageProfile = profileName(cfg)
+	return usageMeter
+}
+
+// writeHAR saves the traffic recorded for --har, if any.
+func writeHAR() {
+	if harLog == nil {
+		return
+	}
+	if err := harLog.WriteFile(globalFlags.HARFile); err != nil {
+		fmt.Fprintln(stderr, "⚠️  Failed to write HAR file:", err)
+		return
+	}
+	fmt.Fprintln(stderr, "HAR file saved to:", globalFlags.HARFile)
+}
+
+// verbosef writes a diagnostic line to stderr when --verbose or --debug is
+// set.
+func verbosef(format string, args ...interface{}) {
+	if globalFlags.Verbose || globalFlags.Debug {
+		fmt.Fprintf(stderr, "[ipgeolocation] "+format+"\n", args...)
+	}
+}
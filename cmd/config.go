@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
 
 	"github.com/spf13/cobra"
 )
@@ -17,41 +16,60 @@ var configCmd = &cobra.Command{
 	Long: `The 'config' command is used to set or retrieve your saved ipgeolocation.io API key.
 
 You can securely save your API key for future use, so you don't need to pass it with every command.
-If no flag is passed, the currently stored API key will be displayed.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if apikey != "" {
-			encrypted, err := utils.EncryptString(apikey)
-			if err != nil {
-				fmt.Println("❌ Failed to encrypt API key:", err)
-				return
+Passing the global --api-url flag saves a custom API base URL, e.g. a caching proxy or a mock server.
+If no flag is passed, the active configuration will be displayed, including which source
+the API key was taken from. Keys are looked up in this order:
+
+  1. the --api-key flag
+  2. the IPGEOLOCATION_API_KEY environment variable
+  3. the active profile (see 'ipgeolocation config profiles')
+  4. the config file`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiURLChanged := cmd.Flags().Changed("api-url")
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if apikey != "" || apiURLChanged {
+			if apikey != "" {
+				store, _ := secretStore(&cfg, "")
+				if err := store.Set(apikey); err != nil {
+					return err
+				}
 			}
-			cfg := config.Config{ApiKey: encrypted}
-			if err := config.Save(cfg); err != nil {
-				fmt.Println("❌ Failed to save config:", err)
-				return
+			if apiURLChanged {
+				cfg.ApiURL = globalFlags.APIURL
 			}
-			fmt.Println("✅ API key saved securely.")
-		} else {
-			cfg, err := config.Load()
-			if err != nil {
-				fmt.Println("❌ Failed to load config:", err)
-				return
+			if err := config.Save(cfg); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
 			}
-
-			if cfg.ApiKey == "" {
-				fmt.Println("⚠️  No API key configured.")
-				return
+			if apikey != "" {
+				fmt.Println("✅ API key saved securely.")
 			}
-			// Mask all but last 5 characters
-			masked := "********"
-			if len(cfg.ApiKey) > 5 {
-				masked += cfg.ApiKey[len(cfg.ApiKey)-5:]
-			} else {
-				masked += cfg.ApiKey
+			if apiURLChanged {
+				fmt.Println("✅ API URL saved:", cfg.ApiURL)
 			}
+			return nil
+		}
 
-			fmt.Println("🔐 Current API key:", masked)
+		if name, _, err := activeProfile(cfg); err != nil {
+			return err
+		} else if name != "" {
+			fmt.Println("👤 Profile:", name)
+		}
+		fmt.Println("🌐 API URL:", resolveAPIURL(cfg))
+
+		key, source, err := resolveAPIKey(cfg)
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			fmt.Println("⚠️  No API key configured.")
+			return nil
 		}
+		fmt.Printf("🔐 Current API key: %s (from %s)\n", maskKey(key), source)
+		return nil
 	},
 }
 
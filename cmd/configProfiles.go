@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/IPGeolocation/cli/v2/internal/config"
+	"github.com/IPGeolocation/cli/v2/internal/output"
+	"github.com/IPGeolocation/cli/v2/internal/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var profileFlags struct {
+	ApiKey           string
+	Language         string
+	Output           string
+	SecretStore      string
+	SecretPath       string
+	SecretCommand    string
+	SecretSetCommand string
+}
+
+var configProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "Manage named configuration profiles",
+	Long: `The 'profiles' command manages named configuration profiles, each with its own API key,
+API base URL, default language and default output format.
+
+A profile is selected with the global --profile flag, the IPGEOLOCATION_PROFILE environment
+variable or 'ipgeolocation config profiles use <name>', in that order.
+
+Examples:
+
+  # Add a production profile and make it the default
+  ipgeolocation config profiles add production --apikey=<paid-key> --output yaml
+  ipgeolocation config profiles use production
+
+  # Run a single command with another profile
+  ipgeolocation ipgeo --ip 8.8.8.8 --profile sandbox
+
+  # Read the key from a password manager instead of the config file
+  ipgeolocation config profiles add production --secret-store command --secret-command "pass show ipgeo"`,
+}
+
+var configProfilesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configuration profiles",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if len(cfg.Profiles) == 0 {
+			fmt.Println("⚠️  No profiles configured. Run: ipgeolocation config profiles add <name> --apikey=<your-key>")
+			return nil
+		}
+
+		names := make([]string, 0, len(cfg.Profiles))
+		for name := range cfg.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		active := profileName(cfg)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\tNAME\tSECRET STORE\tAPI URL\tLANG\tOUTPUT")
+		for _, name := range names {
+			p := cfg.Profiles[name]
+			marker := ""
+			if name == active {
+				marker = "*"
+			}
+			// Only describe the store here; reading every key could run
+			// commands or prompt for keyring access.
+			store := "-"
+			if s, err := secretStore(&cfg, name); err == nil {
+				store = s.String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", marker, name, store, orDash(p.ApiURL), orDash(p.Language), orDash(p.Output))
+		}
+		return w.Flush()
+	},
+}
+
+var configProfilesAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a profile or update an existing one",
+	Long: `Add a profile or update an existing one. Only the settings passed as flags are changed.
+Use the global --api-url flag to set the profile's API base URL, and the global --proxy, --ca-cert,
+--client-cert, --client-key and --insecure-skip-verify flags to set its network settings,
+--rate and --burst to limit its request rate, and --offline to make it answer from the response
+cache only.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		name := args[0]
+		if err := secret.ValidateName(name); err != nil {
+			return &usageError{msg: err.Error()}
+		}
+		if cfg.Profiles == nil {
+			cfg.Profiles = map[string]*config.Profile{}
+		}
+		p, exists := cfg.Profiles[name]
+		if !exists {
+			p = &config.Profile{}
+			cfg.Profiles[name] = p
+		}
+		if cmd.Flags().Changed("api-url") {
+			p.ApiURL = globalFlags.APIURL
+		}
+		if cmd.Flags().Changed("proxy") {
+			p.Proxy = globalFlags.Proxy
+		}
+		if cmd.Flags().Changed("ca-cert") {
+			p.CACert = globalFlags.CACert
+		}
+		if cmd.Flags().Changed("client-cert") {
+			p.ClientCert = globalFlags.ClientCert
+		}
+		if cmd.Flags().Changed("client-key") {
+			p.ClientKey = globalFlags.ClientKey
+		}
+		if cmd.Flags().Changed("insecure-skip-verify") {
+			p.InsecureSkipVerify = globalFlags.InsecureSkipVerify
+		}
+		if cmd.Flags().Changed("offline") {
+			p.Offline = globalFlags.Offline
+		}
+		if cmd.Flags().Changed("rate") {
+			p.Rate = globalFlags.Rate
+		}
+		if cmd.Flags().Changed("burst") {
+			p.Burst = globalFlags.Burst
+		}
+		if cmd.Flags().Changed("lang") {
+			p.Language = profileFlags.Language
+		}
+		if cmd.Flags().Changed("output") {
+			p.Output = profileFlags.Output
+		}
+
+		if err := updateProfileSecretStore(cmd, &cfg, name); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("apikey") {
+			store, err := secretStore(&cfg, name)
+			if err != nil {
+				return err
+			}
+			if err := store.Set(profileFlags.ApiKey); err != nil {
+				return fmt.Errorf("failed to store API key in %s: %w", store, err)
+			}
+		}
+
+		if err := config.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		if exists {
+			fmt.Printf("✅ Profile %q updated.\n", name)
+		} else {
+			fmt.Printf("✅ Profile %q added.\n", name)
+		}
+		return nil
+	},
+}
+
+var configProfilesRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		name := args[0]
+		if _, ok := cfg.Profiles[name]; !ok {
+			return usageErrorf("profile %q not found", name)
+		}
+		deleteProfileKey(&cfg, name)
+		delete(cfg.Profiles, name)
+		if cfg.CurrentProfile == name {
+			cfg.CurrentProfile = ""
+		}
+
+		if err := config.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("✅ Profile %q removed.\n", name)
+		return nil
+	},
+}
+
+var configProfilesUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a profile the default",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		name := args[0]
+		if _, ok := cfg.Profiles[name]; !ok {
+			return usageErrorf("profile %q not found", name)
+		}
+		cfg.CurrentProfile = name
+
+		if err := config.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("✅ Now using profile %q.\n", name)
+		return nil
+	},
+}
+
+// updateProfileSecretStore applies the --secret-* flags to a profile. When
+// the backend changes, the existing key is moved to the new store.
+func updateProfileSecretStore(cmd *cobra.Command, cfg *config.Config, name string) error {
+	flags := cmd.Flags()
+	if !flags.Changed("secret-store") && !flags.Changed("secret-path") &&
+		!flags.Changed("secret-command") && !flags.Changed("secret-set-command") {
+		return nil
+	}
+
+	p := cfg.Profiles[name]
+	oldStore, err := secretStore(cfg, name)
+	if err != nil {
+		return err
+	}
+
+	settings := config.SecretStore{Backend: secret.BackendEncrypted}
+	if p.SecretStore != nil {
+		settings = *p.SecretStore
+	}
+	if flags.Changed("secret-store") {
+		if err := secret.ValidateBackend(profileFlags.SecretStore); err != nil {
+			return &usageError{msg: err.Error()}
+		}
+		if profileFlags.SecretStore != settings.Backend {
+			settings = config.SecretStore{Backend: profileFlags.SecretStore}
+		}
+	}
+	if flags.Changed("secret-path") {
+		settings.Path = profileFlags.SecretPath
+	}
+	if flags.Changed("secret-command") {
+		settings.Command = profileFlags.SecretCommand
+	}
+	if flags.Changed("secret-set-command") {
+		settings.SetCommand = profileFlags.SecretSetCommand
+	}
+	if settings.Backend == secret.BackendCommand && settings.Command == "" {
+		return usageErrorf("the command secret store needs --secret-command")
+	}
+
+	if settings.Backend == secret.BackendEncrypted {
+		p.SecretStore = nil
+	} else {
+		p.SecretStore = &settings
+	}
+	newStore, err := secretStore(cfg, name)
+	if err != nil {
+		return err
+	}
+	if newStore.String() == oldStore.String() || flags.Changed("apikey") {
+		return nil
+	}
+
+	// Carry the existing key over so that switching stores keeps working.
+	key, err := readKey(oldStore)
+	if err != nil || key == "" {
+		return err
+	}
+	if err := newStore.Set(key); err != nil {
+		if errors.Is(err, secret.ErrReadOnly) {
+			fmt.Fprintf(stderr, "⚠️  %s is read-only; store the API key there yourself.\n", newStore)
+			return nil
+		}
+		return fmt.Errorf("failed to move API key to %s: %w", newStore, err)
+	}
+	if err := oldStore.Delete(); err != nil && !errors.Is(err, secret.ErrReadOnly) {
+		fmt.Fprintf(stderr, "⚠️  Could not remove the API key from %s: %v\n", oldStore, err)
+	}
+	return nil
+}
+
+// deleteProfileKey removes the key of a profile that is being removed from
+// its secret store, unless another profile uses the same store. Failures
+// are reported but do not stop the removal.
+func deleteProfileKey(cfg *config.Config, name string) {
+	store, err := secretStore(cfg, name)
+	if err != nil {
+		fmt.Fprintf(stderr, "⚠️  Could not remove the API key of profile %q: %v\n", name, err)
+		return
+	}
+	if _, ok := store.(*secret.EncryptedStore); ok {
+		// The key goes with the profile's entry in the config file.
+		return
+	}
+	for other := range cfg.Profiles {
+		if s, err := secretStore(cfg, other); other != name && err == nil && s.String() == store.String() {
+			return
+		}
+	}
+	if err := store.Delete(); err != nil {
+		if errors.Is(err, secret.ErrReadOnly) {
+			fmt.Fprintf(stderr, "⚠️  %s is read-only; remove the API key there yourself if it is no longer needed.\n", store)
+			return
+		}
+		fmt.Fprintf(stderr, "⚠️  Could not remove the API key from %s: %v\n", store, err)
+	}
+}
+
+// orDash returns s, or "-" when s is empty, for tabular listings.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	configProfilesAddCmd.Flags().StringVar(&profileFlags.ApiKey, "apikey", "", "API key for the profile")
+	configProfilesAddCmd.Flags().StringVar(&profileFlags.Language, "lang", "", "Default response language for the profile")
+	configProfilesAddCmd.Flags().StringVar(&profileFlags.Output, "output", "", fmt.Sprintf("Default output format for the profile: %s", strings.Join(output.Names(), ", ")))
+	configProfilesAddCmd.Flags().StringVar(&profileFlags.SecretStore, "secret-store", "", "Where to keep the API key: encrypted, file, command or keyring")
+	configProfilesAddCmd.Flags().StringVar(&profileFlags.SecretPath, "secret-path", "", "Key file for the file secret store (default ~/.ipgeolocation/<profile>.key)")
+	configProfilesAddCmd.Flags().StringVar(&profileFlags.SecretCommand, "secret-command", "", "Command printing the API key, for the command secret store")
+	configProfilesAddCmd.Flags().StringVar(&profileFlags.SecretSetCommand, "secret-set-command", "", "Command reading a new API key on stdin, for the command secret store")
+
+	configProfilesCmd.AddCommand(configProfilesListCmd, configProfilesAddCmd, configProfilesRemoveCmd, configProfilesUseCmd)
+	configCmd.AddCommand(configProfilesCmd)
+}
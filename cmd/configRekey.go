@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/IPGeolocation/cli/v2/internal/config"
+	"github.com/IPGeolocation/cli/v2/internal/secret"
+	"github.com/IPGeolocation/cli/v2/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var rekeyScheme string
+
+var configRekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "Re-encrypt saved API keys with a new key scheme or passphrase",
+	Long: `The 'rekey' command decrypts every API key kept in the config file and encrypts it again.
+
+Two key schemes are available:
+
+  machine     the key is derived from your home directory (the default). No input is needed,
+              but the config file cannot be moved to another user or machine.
+  passphrase  the key is derived from a passphrase with argon2id. The config file is portable
+              and its keys are useless without the passphrase.
+
+With the passphrase scheme, commands read the passphrase from the IPGEOLOCATION_PASSPHRASE
+environment variable or prompt for it. 'rekey' reads the new passphrase from
+IPGEOLOCATION_NEW_PASSPHRASE or prompts for it twice.
+
+Running 'rekey' without --scheme keeps the current scheme; this changes the passphrase, or
+upgrades keys saved by older versions of the CLI to the current format.
+
+Keys kept in a file, command or keyring secret store are not touched.
+
+Examples:
+
+  # Protect saved keys with a passphrase
+  ipgeolocation config rekey --scheme passphrase
+
+  # Change the passphrase
+  ipgeolocation config rekey
+
+  # Go back to machine-bound encryption
+  ipgeolocation config rekey --scheme machine`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		scheme := cfg.KeyScheme
+		if cmd.Flags().Changed("scheme") {
+			scheme = rekeyScheme
+		}
+		if scheme == "" {
+			scheme = utils.SchemeMachine
+		}
+		if err := secret.ValidateScheme(scheme); err != nil {
+			return &usageError{msg: err.Error()}
+		}
+
+		values := encryptedValues(&cfg)
+		keys := make([]string, len(values))
+		for i, v := range values {
+			if keys[i], err = readKey(encryptedStore(&cfg, v)); err != nil {
+				return err
+			}
+		}
+
+		newPassphrase := ""
+		if scheme == utils.SchemePassphrase {
+			if newPassphrase, err = readNewPassphrase(); err != nil {
+				return err
+			}
+		}
+
+		cfg.KeyScheme = scheme
+		if scheme == utils.SchemeMachine {
+			cfg.KeyScheme = ""
+		}
+		count := 0
+		for i, v := range values {
+			if keys[i] == "" {
+				continue
+			}
+			store := &secret.EncryptedStore{
+				Value:      v,
+				Scheme:     scheme,
+				Passphrase: func() (string, error) { return newPassphrase, nil },
+			}
+			if err := store.Set(keys[i]); err != nil {
+				return err
+			}
+			count++
+		}
+
+		if err := config.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("✅ Re-encrypted %d API key(s) with the %s scheme.\n", count, scheme)
+		return nil
+	},
+}
+
+// encryptedValues returns the config fields holding API keys encrypted
+// into the config file: the top-level key and those of profiles using the
+// encrypted secret store.
+func encryptedValues(cfg *config.Config) []*string {
+	values := []*string{&cfg.ApiKey}
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		if p.SecretStore == nil || p.SecretStore.Backend == secret.BackendEncrypted {
+			values = append(values, &p.ApiKey)
+		}
+	}
+	return values
+}
+
+// readNewPassphrase returns the new passphrase from
+// IPGEOLOCATION_NEW_PASSPHRASE or asks for it twice on the terminal.
+func readNewPassphrase() (string, error) {
+	if v := os.Getenv(envNewPassphrase); v != "" {
+		return v, nil
+	}
+	first, err := promptPassword("New passphrase: ")
+	if err != nil {
+		return "", fmt.Errorf("%w (for the new passphrase, set %s)", err, envNewPassphrase)
+	}
+	if first == "" {
+		return "", usageErrorf("the passphrase must not be empty")
+	}
+	second, err := promptPassword("Repeat new passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if first != second {
+		return "", usageErrorf("passphrases do not match")
+	}
+	return first, nil
+}
+
+func init() {
+	configRekeyCmd.Flags().StringVar(&rekeyScheme, "scheme", "", "Key scheme to encrypt with: machine or passphrase (default: keep the current one)")
+	configCmd.AddCommand(configRekeyCmd)
+}
@@ -0,0 +1,508 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IPGeolocation/cli/v2/client"
+	"github.com/IPGeolocation/cli/v2/internal/config"
+	"github.com/IPGeolocation/cli/v2/internal/output"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configSetting is a plain config file value that 'config get/set' can edit.
+type configSetting struct {
+	get      func(cfg *config.Config) string
+	set      func(cfg *config.Config, value string) error
+	validate func(value string) error
+}
+
+var configSettings = map[string]configSetting{
+	"api_url": {
+		get:      func(cfg *config.Config) string { return cfg.ApiURL },
+		set:      func(cfg *config.Config, value string) error { cfg.ApiURL = value; return nil },
+		validate: client.ValidateBaseURL,
+	},
+	"timeout": {
+		get:      func(cfg *config.Config) string { return cfg.Timeout },
+		set:      func(cfg *config.Config, value string) error { cfg.Timeout = value; return nil },
+		validate: validateDuration,
+	},
+	"retry_max_wait": {
+		get:      func(cfg *config.Config) string { return cfg.RetryMaxWait },
+		set:      func(cfg *config.Config, value string) error { cfg.RetryMaxWait = value; return nil },
+		validate: validateDuration,
+	},
+	"proxy": {
+		get:      func(cfg *config.Config) string { return cfg.Proxy },
+		set:      func(cfg *config.Config, value string) error { cfg.Proxy = value; return nil },
+		validate: validateProxy,
+	},
+	"ca_cert": {
+		get:      func(cfg *config.Config) string { return cfg.CACert },
+		set:      func(cfg *config.Config, value string) error { cfg.CACert = value; return nil },
+		validate: validateFile,
+	},
+	"client_cert": {
+		get:      func(cfg *config.Config) string { return cfg.ClientCert },
+		set:      func(cfg *config.Config, value string) error { cfg.ClientCert = value; return nil },
+		validate: validateFile,
+	},
+	"client_key": {
+		get:      func(cfg *config.Config) string { return cfg.ClientKey },
+		set:      func(cfg *config.Config, value string) error { cfg.ClientKey = value; return nil },
+		validate: validateFile,
+	},
+	"insecure_skip_verify": {
+		get: func(cfg *config.Config) string {
+			if !cfg.InsecureSkipVerify {
+				return ""
+			}
+			return "true"
+		},
+		set: func(cfg *config.Config, value string) error {
+			cfg.InsecureSkipVerify, _ = strconv.ParseBool(value)
+			return nil
+		},
+		validate: validateBool,
+	},
+	"cache.dir": {
+		get: func(cfg *config.Config) string {
+			if cfg.Cache == nil {
+				return ""
+			}
+			return cfg.Cache.Dir
+		},
+		set: func(cfg *config.Config, value string) error {
+			if cfg.Cache == nil {
+				cfg.Cache = &config.CacheSettings{}
+			}
+			cfg.Cache.Dir = value
+			return nil
+		},
+		validate: func(value string) error { return nil },
+	},
+	"cache.disabled": {
+		get: func(cfg *config.Config) string {
+			if cfg.Cache == nil || !cfg.Cache.Disabled {
+				return ""
+			}
+			return "true"
+		},
+		set: func(cfg *config.Config, value string) error {
+			if cfg.Cache == nil {
+				cfg.Cache = &config.CacheSettings{}
+			}
+			cfg.Cache.Disabled, _ = strconv.ParseBool(value)
+			return nil
+		},
+		validate: validateBool,
+	},
+	"retries": {
+		get: func(cfg *config.Config) string {
+			if cfg.Retries == nil {
+				return ""
+			}
+			return strconv.Itoa(*cfg.Retries)
+		},
+		set: func(cfg *config.Config, value string) error {
+			if value == "" {
+				cfg.Retries = nil
+				return nil
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			cfg.Retries = &n
+			return nil
+		},
+		validate: func(value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("must be a non-negative integer")
+			}
+			return nil
+		},
+	},
+	"rate": {
+		get: func(cfg *config.Config) string {
+			if cfg.Rate == 0 {
+				return ""
+			}
+			return strconv.FormatFloat(cfg.Rate, 'f', -1, 64)
+		},
+		set: func(cfg *config.Config, value string) error {
+			cfg.Rate, _ = strconv.ParseFloat(value, 64)
+			return nil
+		},
+		validate: func(value string) error {
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate < 0 {
+				return fmt.Errorf("must be a non-negative number of requests per second")
+			}
+			return nil
+		},
+	},
+	"burst": {
+		get: func(cfg *config.Config) string {
+			if cfg.Burst == 0 {
+				return ""
+			}
+			return strconv.Itoa(cfg.Burst)
+		},
+		set: func(cfg *config.Config, value string) error {
+			cfg.Burst, _ = strconv.Atoi(value)
+			return nil
+		},
+		validate: func(value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("must be a non-negative integer")
+			}
+			return nil
+		},
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Show config file settings",
+	Long: `Show a setting saved in the config file, or all settings starting with the given key.
+Without a key, every saved setting is listed.
+
+See 'ipgeolocation config set --help' for the available keys.
+
+Examples:
+
+  ipgeolocation config get defaults.ipgeo.include
+  ipgeolocation config get defaults`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		prefix := ""
+		if len(args) == 1 {
+			prefix = args[0]
+			if err := validateSettingPrefix(prefix); err != nil {
+				return err
+			}
+			if value, ok := lookupSetting(&cfg, prefix); ok {
+				fmt.Println(value)
+				return nil
+			}
+		}
+
+		for _, kv := range listSettings(&cfg) {
+			if prefix == "" || strings.HasPrefix(kv[0], prefix+".") {
+				fmt.Printf("%s = %s\n", kv[0], kv[1])
+			}
+		}
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Save a config file setting",
+	Long: `Save a setting in the config file. The available keys are:
+
+  api_url, timeout, retries, retry_max_wait          API URL and request behaviour
+  proxy, ca_cert, client_cert, client_key,           network settings
+    insecure_skip_verify
+  cache.dir, cache.disabled, cache.ttl.<endpoint>    response cache, e.g. cache.ttl.asn
+  defaults.<command>.<flag>                          flag defaults, e.g. defaults.ipgeo.include
+
+defaults.<command>.<flag> sets the value a flag takes when it is not given on the command line.
+defaults.global.<flag> applies to every command that has the flag. Explicit flags always win,
+and the active profile's lang and output win over these defaults.
+
+Examples:
+
+  # Always ask ipgeo for security and time zone data
+  ipgeolocation config set defaults.ipgeo.include security,time_zone
+
+  # Print YAML in German by default
+  ipgeolocation config set defaults.global.output yaml
+  ipgeolocation config set defaults.global.lang de
+
+  # Wait up to a minute for slow responses
+  ipgeolocation config set timeout 1m`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		key, value := args[0], args[1]
+		if err := setSetting(&cfg, key, value); err != nil {
+			return err
+		}
+		if err := config.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("✅ %s = %s\n", key, value)
+		return nil
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a config file setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		key := args[0]
+		if err := unsetSetting(&cfg, key); err != nil {
+			return err
+		}
+		if err := config.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("✅ %s removed.\n", key)
+		return nil
+	},
+}
+
+// settingKey is a parsed 'config get/set' key. Command and Flag are only
+// set for defaults.<command>.<flag> keys.
+type settingKey struct {
+	Name    string
+	Command string
+	Flag    *pflag.Flag
+}
+
+// parseSettingKey checks that key names a setting that can be saved.
+func parseSettingKey(key string) (settingKey, error) {
+	if _, ok := configSettings[key]; ok {
+		return settingKey{Name: key}, nil
+	}
+
+	parts := strings.Split(key, ".")
+	if len(parts) != 3 || parts[0] != "defaults" {
+		return settingKey{}, usageErrorf("unknown config key %q; run 'ipgeolocation config set --help' for the available keys", key)
+	}
+
+	f, err := lookupDefaultFlag(parts[1], parts[2])
+	if err != nil {
+		return settingKey{}, usageErrorf("invalid config key %q: %v", key, err)
+	}
+	return settingKey{Name: key, Command: parts[1], Flag: f}, nil
+}
+
+// lookupDefaultFlag returns the flag a defaults.<command>.<flag> entry sets.
+// For the global command, the flag of any command that has it is returned.
+func lookupDefaultFlag(command, flag string) (*pflag.Flag, error) {
+	for _, c := range defaultsCommands() {
+		if command != config.DefaultsGlobal && c.Name() != command {
+			continue
+		}
+		if f := c.LocalNonPersistentFlags().Lookup(flag); f != nil {
+			return f, nil
+		}
+	}
+	if command != config.DefaultsGlobal && findDefaultsCommand(command) == nil {
+		return nil, fmt.Errorf("unknown command %q", command)
+	}
+	return nil, fmt.Errorf("command %q has no --%s flag", command, flag)
+}
+
+// checkDefault validates a defaults.<command>.<flag> entry of the config.
+func checkDefault(command, flag, value string) error {
+	f, err := lookupDefaultFlag(command, flag)
+	if err != nil {
+		return err
+	}
+	return validateFlagValue(f, value)
+}
+
+// validateSettingPrefix checks that prefix is a settable key or a group of
+// them, such as "defaults", "defaults.<command>" or "cache".
+func validateSettingPrefix(prefix string) error {
+	for key := range configSettings {
+		if strings.HasPrefix(key, prefix+".") {
+			return nil
+		}
+	}
+	parts := strings.Split(prefix, ".")
+	switch {
+	case prefix == "defaults":
+		return nil
+	case len(parts) == 2 && parts[0] == "defaults":
+		if parts[1] != config.DefaultsGlobal && findDefaultsCommand(parts[1]) == nil {
+			return usageErrorf("unknown command %q in %q", parts[1], prefix)
+		}
+		return nil
+	}
+	_, err := parseSettingKey(prefix)
+	return err
+}
+
+// defaultsCommands returns the commands that take defaults from the config.
+func defaultsCommands() []*cobra.Command {
+	var commands []*cobra.Command
+	for _, c := range rootCmd.Commands() {
+		if acceptsDefaults(c) {
+			commands = append(commands, c)
+		}
+	}
+	return commands
+}
+
+func findDefaultsCommand(name string) *cobra.Command {
+	for _, c := range defaultsCommands() {
+		if c.Name() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func lookupSetting(cfg *config.Config, key string) (string, bool) {
+	if s, ok := configSettings[key]; ok {
+		return s.get(cfg), true
+	}
+	parts := strings.Split(key, ".")
+	if len(parts) == 3 && parts[0] == "defaults" {
+		value, ok := cfg.Defaults[parts[1]][parts[2]]
+		return value, ok
+	}
+	return "", false
+}
+
+// listSettings returns every saved setting as sorted key/value pairs.
+func listSettings(cfg *config.Config) [][2]string {
+	var settings [][2]string
+	for key, s := range configSettings {
+		if value := s.get(cfg); value != "" {
+			settings = append(settings, [2]string{key, value})
+		}
+	}
+	for command, flags := range cfg.Defaults {
+		for flag, value := range flags {
+			settings = append(settings, [2]string{"defaults." + command + "." + flag, value})
+		}
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i][0] < settings[j][0] })
+	return settings
+}
+
+func setSetting(cfg *config.Config, key, value string) error {
+	k, err := parseSettingKey(key)
+	if err != nil {
+		return err
+	}
+
+	if k.Flag == nil {
+		s := configSettings[key]
+		if err := s.validate(value); err != nil {
+			return usageErrorf("invalid value for %s: %v", key, err)
+		}
+		return s.set(cfg, value)
+	}
+
+	if err := validateFlagValue(k.Flag, value); err != nil {
+		return usageErrorf("invalid value for %s: %v", key, err)
+	}
+	if cfg.Defaults == nil {
+		cfg.Defaults = map[string]map[string]string{}
+	}
+	if cfg.Defaults[k.Command] == nil {
+		cfg.Defaults[k.Command] = map[string]string{}
+	}
+	cfg.Defaults[k.Command][k.Flag.Name] = value
+	return nil
+}
+
+func unsetSetting(cfg *config.Config, key string) error {
+	if s, ok := configSettings[key]; ok {
+		return s.set(cfg, "")
+	}
+	parts := strings.Split(key, ".")
+	if len(parts) != 3 || parts[0] != "defaults" {
+		return usageErrorf("unknown config key %q", key)
+	}
+	command, flag := parts[1], parts[2]
+	if _, ok := cfg.Defaults[command][flag]; !ok {
+		return usageErrorf("%s is not set", key)
+	}
+	delete(cfg.Defaults[command], flag)
+	if len(cfg.Defaults[command]) == 0 {
+		delete(cfg.Defaults, command)
+	}
+	return nil
+}
+
+// validateFlagValue checks that value parses as the type of f.
+func validateFlagValue(f *pflag.Flag, value string) error {
+	if f.Name == "output" {
+		_, err := output.Lookup(value)
+		return err
+	}
+	var err error
+	switch f.Value.Type() {
+	case "int":
+		if _, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+	case "float64":
+		if _, err = strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("must be a number")
+		}
+	case "bool":
+		if _, err = strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+	case "duration":
+		return validateDuration(value)
+	}
+	return nil
+}
+
+func validateBool(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("must be true or false")
+	}
+	return nil
+}
+
+func validateProxy(value string) error {
+	_, err := client.ParseProxyURL(value)
+	return err
+}
+
+func validateFile(value string) error {
+	if _, err := os.Stat(value); err != nil {
+		return fmt.Errorf("cannot read %s", value)
+	}
+	return nil
+}
+
+func validateDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd, configSetCmd, configUnsetCmd)
+}
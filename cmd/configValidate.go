@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/IPGeolocation/cli/v2/client"
+	"github.com/IPGeolocation/cli/v2/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check the config file for errors",
+	Long: `The 'validate' command checks a config file for JSON syntax errors, unknown keys and invalid
+values, and reports each problem with its line and column. It checks ~/.ipgeolocation/config.json
+unless another file is given. The file is not changed.
+
+Examples:
+
+  ipgeolocation config validate
+  ipgeolocation config validate ./shared-config.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := config.Path()
+		if len(args) == 1 {
+			path = args[0]
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read config: %w", err)
+		}
+
+		problems := config.Validate(data, config.ValidateHooks{
+			APIURL:  client.ValidateBaseURL,
+			Proxy:   validateProxy,
+			Default: checkDefault,
+		})
+		if len(problems) > 0 {
+			for _, p := range problems {
+				fmt.Printf("%s:%s\n", path, p)
+			}
+			return fmt.Errorf("found %d problem(s) in %s", len(problems), path)
+		}
+		fmt.Printf("✅ %s is valid.\n", path)
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
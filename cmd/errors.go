@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/IPGeolocation/cli/v2/client"
+	"github.com/IPGeolocation/cli/v2/internal/cache"
+	"github.com/IPGeolocation/cli/v2/internal/fixture"
+	"github.com/IPGeolocation/cli/v2/internal/utils"
+)
+
+// Process exit codes. They are part of the CLI's public interface and are
+// documented in the README; do not renumber them.
+const (
+	exitOK          = 0   // success
+	exitError       = 1   // any error not covered below
+	exitUsage       = 2   // invalid flags or arguments, or a 400-class input error
+	exitAuth        = 3   // missing, undecryptable or rejected API key (401)
+	exitPermission  = 4   // endpoint or feature not in the plan (403)
+	exitQuota       = 5   // credits exhausted or rate limited (429)
+	exitNotFound    = 6   // requested resource does not exist (404)
+	exitNetwork     = 7   // connection, DNS, TLS or timeout failure
+	exitServer      = 8   // the API failed (5xx)
+	exitCacheMiss   = 9   // no stored response: offline cache miss or no matching --replay recording
+	exitInterrupted = 130 // cancelled with Ctrl-C or SIGTERM
+)
+
+// usageError marks errors caused by how the CLI was invoked.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// usageErrorf returns a usageError with a formatted message.
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// errNoAPIKey is returned when no API key is configured.
+var errNoAPIKey = errors.New("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
+
+// exitCode maps an error returned by a command to a process exit code.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return exitUsage
+	}
+	// cobra reports unknown subcommands and bad arguments as plain errors.
+	if strings.HasPrefix(err.Error(), "unknown command") || strings.HasPrefix(err.Error(), "accepts ") {
+		return exitUsage
+	}
+
+	if errors.Is(err, errNoAPIKey) || errors.Is(err, utils.ErrPassphraseRequired) || errors.Is(err, utils.ErrDecrypt) {
+		return exitAuth
+	}
+
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return exitCodeForStatus(apiErr.StatusCode)
+	}
+
+	if errors.Is(err, cache.ErrOfflineMiss) || errors.Is(err, fixture.ErrNoMatch) {
+		return exitCacheMiss
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return exitInterrupted
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded) {
+		return exitNetwork
+	}
+
+	return exitError
+}
+
+// exitCodeForStatus maps an API response status to a process exit code.
+func exitCodeForStatus(status int) int {
+	switch {
+	case status == http.StatusUnauthorized:
+		return exitAuth
+	case status == http.StatusForbidden:
+		return exitPermission
+	case status == http.StatusTooManyRequests:
+		return exitQuota
+	case status == http.StatusNotFound:
+		return exitNotFound
+	case status >= 500:
+		return exitServer
+	case status >= 400:
+		return exitUsage
+	}
+	return exitError
+}
@@ -1,18 +1,12 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
 
+	"github.com/IPGeolocation/cli/v2/client"
 	"github.com/IPGeolocation/cli/v2/internal/common"
-	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var securityFlags common.IPSecurityFlags
@@ -39,63 +33,21 @@ Notes:
   - You must have a valid API key configured using: ipgeolocation config --apikey=<your_key>
   - If no --ip flag is provided, it defaults to your current IP address.
   `,
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.Load()
-		if err != nil || cfg.ApiKey == "" {
-			fmt.Println("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
-			return
-		}
-
-		baseURL := "https://api.ipgeolocation.io/v3/security"
-		url := baseURL + "?apiKey=" + cfg.ApiKey
-		if securityFlags.IP != "" {
-			url += "&ip=" + securityFlags.IP
-		}
-
-		if len(securityFlags.Excludes) > 0 {
-			url += "&excludes=" + strings.Join(securityFlags.Excludes, ",")
-		}
-
-		if len(securityFlags.Fields) > 0 {
-			url += "&fields=" + strings.Join(securityFlags.Fields, ",")
-		}
-
-		resp, err := http.Get(url)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient()
 		if err != nil {
-			fmt.Println("Error fetching ip security info:", err)
-			return
-		}
-		defer resp.Body.Close()
-
-		body, _ := io.ReadAll(resp.Body)
-
-		if resp.StatusCode != 200 {
-			fmt.Printf("Error: %s\n", string(body))
-			return
+			return err
 		}
 
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			fmt.Println("Error parsing JSON:", err)
-			return
-		}
-
-		switch securityFlags.Output {
-		case "raw":
-			fmt.Println(string(body))
-		case "table":
-			utils.PrintAsTable(result, 0)
-		case "yaml":
-			yamlData, err := yaml.Marshal(result)
-			if err != nil {
-				fmt.Println("Error converting to YAML:", err)
-				return
-			}
-			fmt.Println(string(yamlData))
-		default:
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(pretty))
+		resp, err := c.Security(cmd.Context(), client.SecurityOptions{
+			IP:       securityFlags.IP,
+			Excludes: securityFlags.Excludes,
+			Fields:   securityFlags.Fields,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch ip security info: %w", err)
 		}
+		return printResponse(securityFlags.Output, "security", resp.Body)
 	},
 }
 
@@ -103,7 +55,7 @@ func init() {
 	ipSecurityCmd.Flags().StringVar(&securityFlags.IP, "ip", "", "IPv4 or IPv6 address (e.g. 8.8.8.8)")
 	ipSecurityCmd.Flags().StringSliceVar(&securityFlags.Excludes, "exclude", []string{}, "Fields to exclude from the output")
 	ipSecurityCmd.Flags().StringSliceVar(&securityFlags.Fields, "fields", []string{}, "Get Specific Fields to include in the output")
-	ipSecurityCmd.Flags().StringVar(&securityFlags.Output, "output", "pretty", "Output format: pretty, raw, table, yaml")
+	addOutputFlag(ipSecurityCmd, &securityFlags.Output)
 
 	rootCmd.AddCommand(ipSecurityCmd)
 }
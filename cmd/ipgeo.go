@@ -1,18 +1,12 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
 
+	"github.com/IPGeolocation/cli/v2/client"
 	"github.com/IPGeolocation/cli/v2/internal/common"
-	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var ipgeoFlags common.IpgeoFlags
@@ -49,71 +43,23 @@ Examples:
 Notes:
   - You must have a valid API key configured using: ipgeolocation config --apikey=<your_key>
   - If no --ip flag is provided, it defaults to your current IP address.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.Load()
-		if err != nil || cfg.ApiKey == "" {
-			fmt.Println("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
-			return
-		}
-
-		baseURL := "https://api.ipgeolocation.io/v3/ipgeo"
-		url := baseURL + "?apiKey=" + cfg.ApiKey
-		if ipgeoFlags.IP != "" {
-			url += "&ip=" + ipgeoFlags.IP
-		}
-
-		if len(ipgeoFlags.Include) > 0 {
-			url += "&include=" + strings.Join(ipgeoFlags.Include, ",")
-		}
-
-		if len(ipgeoFlags.Excludes) > 0 {
-			url += "&excludes=" + strings.Join(ipgeoFlags.Excludes, ",")
-		}
-
-		if len(ipgeoFlags.Fields) > 0 {
-			url += "&fields=" + strings.Join(ipgeoFlags.Fields, ",")
-		}
-
-		if ipgeoFlags.Language != "" {
-			url += "&lang=" + ipgeoFlags.Language
-		}
-
-		resp, err := http.Get(url)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient()
 		if err != nil {
-			fmt.Println("Error fetching IP Geolocation info:", err)
-			return
+			return err
 		}
-		defer resp.Body.Close()
 
-		body, _ := io.ReadAll(resp.Body)
-
-		if resp.StatusCode != 200 {
-			fmt.Printf("Error: %s\n", string(body))
-			return
-		}
-
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			fmt.Println("Error parsing JSON:", err)
-			return
-		}
-
-		switch ipgeoFlags.Output {
-		case "raw":
-			fmt.Println(string(body))
-		case "table":
-			utils.PrintAsTable(result, 0)
-		case "yaml":
-			yamlData, err := yaml.Marshal(result)
-			if err != nil {
-				fmt.Println("Error converting to YAML:", err)
-				return
-			}
-			fmt.Println(string(yamlData))
-		default:
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(pretty))
+		resp, err := c.IPGeo(cmd.Context(), client.IPGeoOptions{
+			IP:       ipgeoFlags.IP,
+			Include:  ipgeoFlags.Include,
+			Excludes: ipgeoFlags.Excludes,
+			Fields:   ipgeoFlags.Fields,
+			Language: ipgeoFlags.Language,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch IP Geolocation info: %w", err)
 		}
+		return printResponse(ipgeoFlags.Output, "ipgeo", resp.Body)
 	},
 }
 
@@ -123,7 +69,7 @@ func init() {
 	ipgeoCmd.Flags().StringSliceVar(&ipgeoFlags.Excludes, "excludes", []string{}, "Fields to exclude from the output")
 	ipgeoCmd.Flags().StringSliceVar(&ipgeoFlags.Fields, "fields", []string{}, "Get Specific Fields to include in the output")
 	ipgeoCmd.Flags().StringVar(&ipgeoFlags.Language, "lang", "", "Language for the output")
-	ipgeoCmd.Flags().StringVar(&ipgeoFlags.Output, "output", "pretty", "Output format: pretty, raw, table, yaml")
+	addOutputFlag(ipgeoCmd, &ipgeoFlags.Output)
 
 	rootCmd.AddCommand(ipgeoCmd)
 }
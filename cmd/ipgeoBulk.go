@@ -2,17 +2,15 @@ package cmd
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/IPGeolocation/cli/v2/client"
 	"github.com/IPGeolocation/cli/v2/internal/common"
-	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var bulkIpgeoFlags common.BulkIpgeoFlags
@@ -37,19 +35,17 @@ Examples:
   # Lookup from file and include location/timezone
   ipgeolocation bulk-ip-geo --file=ips.txt --include=location,time_zone
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.Load()
-		if err != nil || cfg.ApiKey == "" {
-			fmt.Println("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
-			return
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient()
+		if err != nil {
+			return err
 		}
 
 		// If --file is provided, read IPs from file
 		if bulkIpgeoFlags.File != "" {
 			file, err := os.Open(bulkIpgeoFlags.File)
 			if err != nil {
-				fmt.Println("Error opening file:", err)
-				return
+				return fmt.Errorf("failed to open file: %w", err)
 			}
 			defer file.Close()
 
@@ -61,74 +57,38 @@ Examples:
 				}
 			}
 			if err := scanner.Err(); err != nil {
-				fmt.Println("Error reading file:", err)
-				return
+				return fmt.Errorf("failed to read file: %w", err)
 			}
 		}
 
 		if len(bulkIpgeoFlags.IPs) == 0 {
-			fmt.Println("Please provide at least one IP address using --ips or --file.")
-			return
-		}
-
-		baseURL := "https://api.ipgeolocation.io/v3/ipgeo-bulk"
-		url := baseURL + "?apiKey=" + cfg.ApiKey
-
-		if len(bulkIpgeoFlags.Include) > 0 {
-			url += "&include=" + strings.Join(bulkIpgeoFlags.Include, ",")
-		}
-		if len(bulkIpgeoFlags.Excludes) > 0 {
-			url += "&excludes=" + strings.Join(bulkIpgeoFlags.Excludes, ",")
-		}
-		if len(bulkIpgeoFlags.Fields) > 0 {
-			url += "&fields=" + strings.Join(bulkIpgeoFlags.Fields, ",")
-		}
-		if bulkIpgeoFlags.Language != "" {
-			url += "&lang=" + bulkIpgeoFlags.Language
+			return usageErrorf("please provide at least one IP address using --ips or --file")
 		}
 
-		payload := map[string]interface{}{
-			"ips": bulkIpgeoFlags.IPs,
-		}
-
-		body, err := utils.PostJSON(url, payload, map[string]string{"Content-Type": "application/json"})
+		body, result, err := fetchInBatches(cmd.Context(), bulkIpgeoFlags.IPs, bulkIpgeoFlags.BatchSize, func(ctx context.Context, batch []string) (*client.Response, error) {
+			return c.BulkIPGeo(ctx, batch, client.IPGeoOptions{
+				Include:  bulkIpgeoFlags.Include,
+				Excludes: bulkIpgeoFlags.Excludes,
+				Fields:   bulkIpgeoFlags.Fields,
+				Language: bulkIpgeoFlags.Language,
+			})
+		})
 		if err != nil {
-			fmt.Println("Error fetching Bulk IP Security info:", err)
-			return
-		}
-
-		var result interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			fmt.Println("Invalid JSON:", err)
-			return
+			savePartialResults(bulkIpgeoFlags.OutputFile, result, len(bulkIpgeoFlags.IPs))
+			return fmt.Errorf("failed to fetch Bulk IP Geolocation info: %w", err)
 		}
 
-		switch bulkIpgeoFlags.Output {
-		case "raw":
-			fmt.Println(string(body))
-		case "table":
-			utils.PrintAsTable(result, 0)
-		case "yaml":
-			yamlData, err := yaml.Marshal(result)
-			if err != nil {
-				fmt.Println("Error converting to YAML:", err)
-				return
-			}
-			fmt.Println(string(yamlData))
-
-		default:
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(pretty))
+		if err := printResult(bulkIpgeoFlags.Output, "ipgeo-bulk", body, result); err != nil {
+			return err
 		}
 		if bulkIpgeoFlags.OutputFile != "" {
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			err := os.WriteFile(bulkIpgeoFlags.OutputFile+".json", pretty, 0644)
+			path, err := saveOutputFile(bulkIpgeoFlags.OutputFile, result)
 			if err != nil {
-				fmt.Println("Error writing JSON to file:", err)
-				return
+				return fmt.Errorf("failed to write JSON to file: %w", err)
 			}
-			fmt.Println("Output saved to file:", bulkIpgeoFlags.OutputFile+".json")
+			fmt.Println("Output saved to file:", path)
 		}
+		return nil
 	},
 }
 
@@ -138,9 +98,10 @@ func init() {
 	bulkIpgeoCmd.Flags().StringSliceVar(&bulkIpgeoFlags.Excludes, "exclude", []string{}, "Fields to exclude from the output")
 	bulkIpgeoCmd.Flags().StringSliceVar(&bulkIpgeoFlags.Fields, "fields", []string{}, "Get Specific Fields to include in the output")
 	bulkIpgeoCmd.Flags().StringVar(&bulkIpgeoFlags.Language, "lang", "", "Language for the output")
-	bulkIpgeoCmd.Flags().StringVar(&bulkIpgeoFlags.Output, "output", "pretty", "Output format: pretty, raw, table, yaml")
+	addOutputFlag(bulkIpgeoCmd, &bulkIpgeoFlags.Output)
 	bulkIpgeoCmd.Flags().StringVar(&bulkIpgeoFlags.File, "file", "", "Path to a text file containing IPs (one per line)")
 	bulkIpgeoCmd.Flags().StringVar(&bulkIpgeoFlags.OutputFile, "output-file", "", "Save output to a file (JSON only)")
+	bulkIpgeoCmd.Flags().IntVar(&bulkIpgeoFlags.BatchSize, "batch-size", 0, "Send IPs in batches of this size (0 sends all in one request)")
 
 	rootCmd.AddCommand(bulkIpgeoCmd)
 }
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IPGeolocation/cli/v2/internal/mockapi"
+
+	"github.com/spf13/cobra"
+)
+
+var mockServerFlags struct {
+	Host       string
+	Port       int
+	RequireKey string
+	FailStatus int
+	FailEvery  int
+	Latency    time.Duration
+	Time       string
+	Quiet      bool
+}
+
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Serve a local imitation of the IPGeolocation.io API for tests and demos",
+	Long: `The 'mock-server' command serves the IPGeolocation.io v3 API routes locally with synthetic data,
+so that integration tests and demos can run the real CLI without network access or credits.
+
+Every answer is derived from the request: the same IP, ASN, location or user agent always gets
+the same response. Time zone and astronomy answers use the current time unless --time pins it.
+Any API key is accepted unless --require-key is given; requests without one get a 401.
+
+Point the CLI at the server with --api-url or IPGEOLOCATION_API_URL:
+
+  ipgeolocation mock-server --port 8080 &
+  IPGEOLOCATION_API_URL=http://127.0.0.1:8080/v3 ipgeolocation ipgeo --ip 8.8.8.8 --api-key test
+
+Failures can be injected to exercise retries and error handling:
+
+  --fail-status 429 --fail-every 2   every second request is rate limited (with Retry-After)
+  --fail-status 503                  every request fails with a 503
+  --require-key secret               any other key gets a 401
+  --latency 2s                       every response is delayed; combine with --timeout
+
+Press Ctrl-C to stop the server.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f := mockServerFlags
+		if f.Port < 0 || f.Port > 65535 {
+			return usageErrorf("--port must be between 0 and 65535")
+		}
+		if f.FailStatus != 0 && (f.FailStatus < 400 || f.FailStatus > 599) {
+			return usageErrorf("--fail-status must be a 4xx or 5xx status, got %d", f.FailStatus)
+		}
+		if f.FailEvery < 0 {
+			return usageErrorf("--fail-every must not be negative")
+		}
+
+		opts := mockapi.Options{
+			APIKey:     f.RequireKey,
+			FailStatus: f.FailStatus,
+			FailEvery:  f.FailEvery,
+			Latency:    f.Latency,
+		}
+		if f.Time != "" {
+			fixed, err := time.Parse(time.RFC3339, f.Time)
+			if err != nil {
+				return usageErrorf("--time must be an RFC 3339 time such as 2025-06-21T12:00:00Z: %v", err)
+			}
+			opts.Now = func() time.Time { return fixed }
+		}
+		if !f.Quiet {
+			opts.Logf = func(format string, args ...interface{}) {
+				fmt.Fprintf(stderr, time.Now().Format("15:04:05")+" "+format+"\n", args...)
+			}
+		}
+
+		listener, err := net.Listen("tcp", net.JoinHostPort(f.Host, strconv.Itoa(f.Port)))
+		if err != nil {
+			return fmt.Errorf("failed to listen: %w", err)
+		}
+		server := &http.Server{Handler: mockapi.New(opts), ReadHeaderTimeout: 10 * time.Second}
+
+		baseURL := "http://" + listener.Addr().String() + mockapi.PathPrefix
+		fmt.Println("🧪 Mock API listening on", baseURL)
+		fmt.Println("   Use it with: --api-url " + baseURL)
+
+		errc := make(chan error, 1)
+		go func() { errc <- server.Serve(listener) }()
+
+		select {
+		case err := <-errc:
+			return fmt.Errorf("mock server failed: %w", err)
+		case <-cmd.Context().Done():
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("failed to stop mock server: %w", err)
+		}
+		fmt.Fprintln(stderr, "Mock API stopped.")
+		return nil
+	},
+}
+
+func init() {
+	mockServerCmd.Flags().StringVar(&mockServerFlags.Host, "host", "127.0.0.1", "Address to listen on")
+	mockServerCmd.Flags().IntVar(&mockServerFlags.Port, "port", 8080, "Port to listen on (0 picks a free port)")
+	mockServerCmd.Flags().StringVar(&mockServerFlags.RequireKey, "require-key", "", "Only accept this API key; others get a 401")
+	mockServerCmd.Flags().IntVar(&mockServerFlags.FailStatus, "fail-status", 0, "Inject failures with this status, e.g. 401, 429, 500 or 503")
+	mockServerCmd.Flags().IntVar(&mockServerFlags.FailEvery, "fail-every", 1, "Fail every Nth request with --fail-status (1 fails them all)")
+	mockServerCmd.Flags().DurationVar(&mockServerFlags.Latency, "latency", 0, "Delay every response by this long")
+	mockServerCmd.Flags().StringVar(&mockServerFlags.Time, "time", "", "Answer time zone and astronomy requests as if it were this RFC 3339 time")
+	mockServerCmd.Flags().BoolVarP(&mockServerFlags.Quiet, "quiet", "q", false, "Do not log requests to stderr")
+
+	rootCmd.AddCommand(mockServerCmd)
+}
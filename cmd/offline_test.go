@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IPGeolocation/cli/v2/internal/mockapi"
+)
+
+// runCLI runs the CLI with args in a home directory of its own and returns
+// what it printed to stdout.
+func runCLI(t *testing.T, home string, args ...string) (string, error) {
+	t.Helper()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+	t.Setenv(envAPIKey, "")
+	t.Setenv(envAPIURL, "")
+	t.Setenv(envProfile, "")
+	offlineHits.count, offlineHits.age, offlineHits.expired = 0, 0, false
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	done := make(chan []byte)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- out
+	}()
+
+	rootCmd.SetArgs(args)
+	_, err = rootCmd.ExecuteC()
+	w.Close()
+	return string(<-done), err
+}
+
+func TestOffline(t *testing.T) {
+	srv := httptest.NewServer(mockapi.New(mockapi.Options{}))
+	defer srv.Close()
+	home := t.TempDir()
+	apiURL := srv.URL + mockapi.PathPrefix
+
+	if _, err := runCLI(t, home, "ipgeo", "--ip", "8.8.8.8", "--api-key", "k-123", "--api-url", apiURL, "--output", "raw"); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	t.Run("hit", func(t *testing.T) {
+		out, err := runCLI(t, home, "ipgeo", "--ip", "8.8.8.8", "--api-url", apiURL, "--offline", "--output", "csv", "--columns", "ip,_cache")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "ip,_cache.cached,_cache.age_seconds,_cache.expired\n8.8.8.8,true,"
+		if !strings.HasPrefix(out, want) || !strings.HasSuffix(out, ",false\n") {
+			t.Errorf("got %q, want a row marked as cached", out)
+		}
+
+		out, err = runCLI(t, home, "ipgeo", "--ip", "8.8.8.8", "--api-url", apiURL, "--offline", "--output", "raw")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(out, cacheField) {
+			t.Errorf("raw output %q was changed", out)
+		}
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		_, err := runCLI(t, home, "ipgeo", "--ip", "9.9.9.9", "--api-url", apiURL, "--offline", "--output", "raw")
+		if code := exitCode(err); code != exitCacheMiss {
+			t.Errorf("offline miss: exit code %d (%v), want %d", code, err, exitCacheMiss)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/IPGeolocation/cli/v2/client"
+	"github.com/IPGeolocation/cli/v2/internal/output"
+	"github.com/IPGeolocation/cli/v2/internal/utils"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// errOutputListed is returned after `--output help` has listed the formats,
+// to stop the command before it sends a request. Execute treats it as
+// success.
+var errOutputListed = errors.New("output formats listed")
+
+// outputOptions holds the flags tuning the tabular formats, shared by every
+// command as only one runs at a time.
+var outputOptions struct {
+	Columns        []string
+	ArraySeparator string
+	Border         string
+	SortKeys       bool
+}
+
+// addOutputFlag adds the --output flag, and the flags tuning the tabular
+// formats, shared by every command printing an API response.
+func addOutputFlag(cmd *cobra.Command, p *string) {
+	cmd.Flags().StringVar(p, "output", output.Default, fmt.Sprintf("Output format: %s (%q lists them)", strings.Join(output.Names(), ", "), output.Help))
+	cmd.Flags().StringSliceVar(&outputOptions.Columns, "columns", nil, "Columns to print with --output csv, tsv or table, in order, e.g. ip,location.city (a name also selects the fields nested under it)")
+	cmd.Flags().StringVar(&outputOptions.ArraySeparator, "array-separator", output.DefaultArraySeparator, "Separator joining array values into one cell with --output csv, tsv or table")
+	cmd.Flags().BoolVar(&outputOptions.SortKeys, "sort-keys", false, "Print fields in alphabetical order instead of the order the API sent them")
+	cmd.Flags().StringVar(&outputOptions.Border, "border", output.BorderBox, fmt.Sprintf("Border style of the --output table grid: %s", strings.Join(output.Borders, ", ")))
+}
+
+// checkOutputFlag validates the --output flag of cmd, if it has one, once
+// config defaults are applied, so that a typo fails before any request is
+// sent. `--output help` lists the formats instead.
+func checkOutputFlag(cmd *cobra.Command) error {
+	f := cmd.LocalNonPersistentFlags().Lookup("output")
+	if f == nil {
+		return nil
+	}
+	if f.Value.String() == output.Help {
+		printOutputFormats()
+		return errOutputListed
+	}
+	if _, err := output.Lookup(f.Value.String()); err != nil {
+		return usageErrorf("%v\nRun with --output %s to describe them.", err, output.Help)
+	}
+	if err := output.ValidateBorder(outputOptions.Border); err != nil {
+		return &usageError{msg: err.Error()}
+	}
+	return nil
+}
+
+// printOutputFormats lists the registered output formats.
+func printOutputFormats() {
+	fmt.Println("Available output formats:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, f := range output.Formats() {
+		fmt.Fprintf(w, "  %s\t%s\n", f.Name, f.Description)
+	}
+	w.Flush()
+}
+
+// printResponse decodes an API response body, keeping the order of its
+// fields, and prints it in format.
+func printResponse(format, name string, body []byte) error {
+	result, err := client.DecodeJSON(body)
+	if err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return printResult(format, name, body, result)
+}
+
+// printResult prints an already decoded API response in format. With
+// --sort-keys, the fields of result are sorted first. Responses served from
+// the cache in offline mode get a cacheField, except in the raw format.
+func printResult(format, name string, body []byte, result interface{}) error {
+	if outputOptions.SortKeys {
+		utils.SortKeys(result)
+	}
+	data := result
+	if offlineHits.count > 0 && format != "raw" {
+		data = withCacheField(result)
+	}
+	return output.Render(os.Stdout, format, &output.Document{
+		Name:           name,
+		Raw:            body,
+		Data:           data,
+		Columns:        outputOptions.Columns,
+		ArraySeparator: outputOptions.ArraySeparator,
+		Border:         outputOptions.Border,
+		Width:          terminalWidth(),
+	})
+}
+
+// cacheField is the member added to responses served from the cache in
+// offline mode. It holds the age of the oldest response used, and whether
+// any of them was past its TTL.
+const cacheField = "_cache"
+
+// withCacheField returns result with a cacheField added to it or, for an
+// array, to each of its objects. result itself is left as it is, as bulk
+// commands still save it to --output-file.
+func withCacheField(result interface{}) interface{} {
+	info := &client.OrderedMap{Values: map[string]interface{}{}}
+	info.Set("cached", true)
+	info.Set("age_seconds", int64(offlineHits.age.Seconds()))
+	info.Set("expired", offlineHits.expired)
+
+	mark := func(v interface{}) interface{} {
+		m, ok := v.(*client.OrderedMap)
+		if !ok {
+			return v
+		}
+		marked := &client.OrderedMap{Values: make(map[string]interface{}, len(m.Values)+1)}
+		for _, k := range m.Keys {
+			marked.Set(k, m.Values[k])
+		}
+		marked.Set(cacheField, info)
+		return marked
+	}
+	items, ok := result.([]interface{})
+	if !ok {
+		return mark(result)
+	}
+	marked := make([]interface{}, len(items))
+	for i, item := range items {
+		marked[i] = mark(item)
+	}
+	return marked
+}
+
+// terminalWidth returns the width of the terminal stdout writes to, or the
+// COLUMNS environment variable, or 0 when output is not to a terminal.
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+	return width
+}
@@ -4,15 +4,11 @@ Copyright © 2026 IPGeolocation.io <support@ipgeolocation.io>
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/IPGeolocation/cli/v2/internal/common"
-	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var bulkUserAgentsFlags common.ParseBulkUserAgentFlags
@@ -43,60 +39,27 @@ Note:
 
   `,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.Load()
-		if err != nil || cfg.ApiKey == "" {
-			fmt.Println("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
-			return
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient()
+		if err != nil {
+			return err
 		}
 
 		if len(bulkUserAgentsFlags.UserAgents) == 0 {
-			fmt.Println("Please provide at least one user agent.")
-			return
+			return usageErrorf("please provide at least one user agent")
 		}
 
-		baseURL := "https://api.ipgeolocation.io/v3/user-agent-bulk"
-		url := baseURL + "?apiKey=" + cfg.ApiKey
-
-		payload := map[string]interface{}{
-			"uaStrings": bulkUserAgentsFlags.UserAgents,
-		}
-		body, err := utils.PostJSON(url, payload, map[string]string{
-			"Content-Type": "application/json",
-		})
+		resp, err := c.ParseBulkUserAgents(cmd.Context(), bulkUserAgentsFlags.UserAgents)
 		if err != nil {
-			fmt.Println("Error fetching user agents info:", err)
-			return
+			return fmt.Errorf("failed to fetch user agents info: %w", err)
 		}
-
-		var result interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			fmt.Println("Invalid JSON:", err)
-			return
-		}
-		switch bulkUserAgentsFlags.Output {
-		case "raw":
-			fmt.Println(string(body))
-		case "table":
-			utils.PrintAsTable(result, 0)
-		case "yaml":
-			yamlData, err := yaml.Marshal(result)
-			if err != nil {
-				fmt.Println("Error converting to YAML:", err)
-				return
-			}
-			fmt.Println(string(yamlData))
-		default:
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(pretty))
-		}
-
+		return printResponse(bulkUserAgentsFlags.Output, "user-agent-bulk", resp.Body)
 	},
 }
 
 func init() {
 	parseBulkUserAgentsCmd.Flags().StringSliceVar(&bulkUserAgentsFlags.UserAgents, "user-agents", []string{}, "User Agents")
-	parseBulkUserAgentsCmd.Flags().StringVar(&bulkUserAgentsFlags.Output, "output", "", "Output format:  raw, table, yaml")
+	addOutputFlag(parseBulkUserAgentsCmd, &bulkUserAgentsFlags.Output)
 	rootCmd.AddCommand(parseBulkUserAgentsCmd)
 
 }
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/IPGeolocation/cli/v2/internal/config"
+	"github.com/IPGeolocation/cli/v2/internal/redact"
+	"github.com/IPGeolocation/cli/v2/internal/secret"
+	"github.com/IPGeolocation/cli/v2/internal/utils"
+
+	"golang.org/x/term"
+)
+
+// secretStore returns the store holding the API key of the named profile,
+// or of the top-level config when name is empty. The encrypted store writes
+// into cfg, so callers must save cfg after changing the key.
+func secretStore(cfg *config.Config, name string) (secret.Store, error) {
+	if name == "" {
+		return encryptedStore(cfg, &cfg.ApiKey), nil
+	}
+
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return nil, usageErrorf("profile %q not found", name)
+	}
+	if p.SecretStore == nil {
+		return encryptedStore(cfg, &p.ApiKey), nil
+	}
+
+	s := p.SecretStore
+	switch s.Backend {
+	case secret.BackendEncrypted:
+		return encryptedStore(cfg, &p.ApiKey), nil
+	case secret.BackendFile:
+		path := s.Path
+		if path == "" {
+			if err := secret.ValidateName(name); err != nil {
+				return nil, fmt.Errorf("%w; set a --secret-path for the profile", err)
+			}
+			path = defaultKeyFile(name)
+		}
+		return &secret.FileStore{Path: path}, nil
+	case secret.BackendCommand:
+		return &secret.CommandStore{GetCommand: s.Command, SetCommand: s.SetCommand}, nil
+	case secret.BackendKeyring:
+		account := s.Account
+		if account == "" {
+			account = name
+		}
+		return &secret.KeyringStore{Service: s.Service, Account: account}, nil
+	}
+	return nil, fmt.Errorf("profile %q: %w", name, secret.ValidateBackend(s.Backend))
+}
+
+// encryptedStore returns a store encrypting into value with the config's
+// key scheme.
+func encryptedStore(cfg *config.Config, value *string) *secret.EncryptedStore {
+	return &secret.EncryptedStore{Value: value, Scheme: cfg.KeyScheme, Passphrase: passphrase}
+}
+
+// cachedPassphrase keeps the passphrase for the rest of the run once it has
+// been entered.
+var cachedPassphrase string
+
+// passphrase returns the config passphrase from IPGEOLOCATION_PASSPHRASE or,
+// when stdin is a terminal, by prompting for it.
+func passphrase() (string, error) {
+	if cachedPassphrase != "" {
+		return cachedPassphrase, nil
+	}
+	v := os.Getenv(envPassphrase)
+	if v == "" {
+		var err error
+		if v, err = promptPassword("Config passphrase: "); err != nil {
+			return "", err
+		}
+	}
+	redact.Add(v)
+	cachedPassphrase = v
+	return v, nil
+}
+
+// promptPassword reads a line from the terminal without echoing it. The
+// prompt goes to stderr so that it does not end up in piped output.
+func promptPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("%w; set %s", utils.ErrPassphraseRequired, envPassphrase)
+	}
+	fmt.Fprint(stderr, prompt)
+	data, err := term.ReadPassword(fd)
+	fmt.Fprintln(stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(data), nil
+}
+
+// defaultKeyFile is where the file store keeps a profile's key when no
+// path is configured.
+func defaultKeyFile(profile string) string {
+	return filepath.Join(config.Dir(), profile+".key")
+}
+
+// readKey reads the key from store, treating an empty store as no key.
+func readKey(store secret.Store) (string, error) {
+	key, err := store.Get()
+	if errors.Is(err, secret.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key from %s: %w", store, err)
+	}
+	return key, nil
+}
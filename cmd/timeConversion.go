@@ -1,19 +1,12 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 
+	"github.com/IPGeolocation/cli/v2/client"
 	"github.com/IPGeolocation/cli/v2/internal/common"
-	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
-
-	encoding "net/url"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var timeConversionFlags common.TimeConversionFlags
@@ -52,112 +45,33 @@ Notes:
   - You must configure your API key first using: ipgeolocation config --apikey=<your_key>
   - If multiple location inputs are provided, precedence may depend on the API's logic.
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.Load()
-		if err != nil || cfg.ApiKey == "" {
-			fmt.Println("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
-			return
-		}
-
-		baseURL := "https://api.ipgeolocation.io/v3/timezone/convert"
-		url := baseURL + "?apiKey=" + cfg.ApiKey
-
-		if timeConversionFlags.TimezoneFrom != "" {
-			url += "&tz_from=" + timeConversionFlags.TimezoneFrom
-		}
-
-		if timeConversionFlags.TimezoneTo != "" {
-			url += "&tz_to=" + timeConversionFlags.TimezoneTo
-		}
-
-		if timeConversionFlags.LocationFrom != "" {
-			url += "&location_from=" + encoding.QueryEscape(timeConversionFlags.LocationFrom)
-		}
-
-		if timeConversionFlags.LocationTo != "" {
-			url += "&location_to=" + encoding.QueryEscape(timeConversionFlags.LocationTo)
-		}
-
-		if timeConversionFlags.LatitudeFrom != 0 {
-			url += "&lat_from=" + fmt.Sprintf("%f", timeConversionFlags.LatitudeFrom)
-		}
-
-		if timeConversionFlags.LongitudeFrom != 0 {
-			url += "&long_from=" + fmt.Sprintf("%f", timeConversionFlags.LongitudeFrom)
-		}
-
-		if timeConversionFlags.LatitudeTo != 0 {
-			url += "&lat_to=" + fmt.Sprintf("%f", timeConversionFlags.LatitudeTo)
-		}
-
-		if timeConversionFlags.LongitudeTo != 0 {
-			url += "&long_to=" + fmt.Sprintf("%f", timeConversionFlags.LongitudeTo)
-		}
-
-		if timeConversionFlags.IataCodeFrom != "" {
-			url += "&iata_from=" + timeConversionFlags.IataCodeFrom
-		}
-
-		if timeConversionFlags.IataCodeTo != "" {
-			url += "&iata_to=" + timeConversionFlags.IataCodeTo
-		}
-
-		if timeConversionFlags.IcaoCodeFrom != "" {
-			url += "&icao_from=" + timeConversionFlags.IcaoCodeFrom
-		}
-
-		if timeConversionFlags.IcaoCodeTo != "" {
-			url += "&icao_to=" + timeConversionFlags.IcaoCodeTo
-		}
-
-		if timeConversionFlags.LoCodeFrom != "" {
-			url += "&locode_from=" + timeConversionFlags.LoCodeFrom
-		}
-
-		if timeConversionFlags.LoCodeTo != "" {
-			url += "&locode_to=" + timeConversionFlags.LoCodeTo
-		}
-
-		if timeConversionFlags.Time != "" {
-			url += "&time=" + timeConversionFlags.Time
-		}
-
-		resp, err := http.Get(url)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient()
 		if err != nil {
-			fmt.Println("Error fetching time info:", err)
-			return
-		}
-		defer resp.Body.Close()
-
-		body, _ := io.ReadAll(resp.Body)
-
-		if resp.StatusCode != 200 {
-			fmt.Printf("Error: %s\n", string(body))
-			return
-		}
-
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			fmt.Println("Error parsing JSON:", err)
-			return
-		}
-
-		switch timeConversionFlags.Output {
-		case "raw":
-			fmt.Println(string(body))
-		case "table":
-			utils.PrintAsTable(result, 0)
-		case "yaml":
-			yamlData, err := yaml.Marshal(result)
-			if err != nil {
-				fmt.Println("Error converting to YAML:", err)
-				return
-			}
-			fmt.Println(string(yamlData))
-		default:
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(pretty))
+			return err
+		}
+
+		resp, err := c.ConvertTime(cmd.Context(), client.ConvertTimeOptions{
+			TimezoneFrom:  timeConversionFlags.TimezoneFrom,
+			TimezoneTo:    timeConversionFlags.TimezoneTo,
+			LocationFrom:  timeConversionFlags.LocationFrom,
+			LocationTo:    timeConversionFlags.LocationTo,
+			LatitudeFrom:  timeConversionFlags.LatitudeFrom,
+			LongitudeFrom: timeConversionFlags.LongitudeFrom,
+			LatitudeTo:    timeConversionFlags.LatitudeTo,
+			LongitudeTo:   timeConversionFlags.LongitudeTo,
+			IataCodeFrom:  timeConversionFlags.IataCodeFrom,
+			IataCodeTo:    timeConversionFlags.IataCodeTo,
+			IcaoCodeFrom:  timeConversionFlags.IcaoCodeFrom,
+			IcaoCodeTo:    timeConversionFlags.IcaoCodeTo,
+			LoCodeFrom:    timeConversionFlags.LoCodeFrom,
+			LoCodeTo:      timeConversionFlags.LoCodeTo,
+			Time:          timeConversionFlags.Time,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch time info: %w", err)
 		}
+		return printResponse(timeConversionFlags.Output, "timezone/convert", resp.Body)
 	},
 }
 
@@ -177,7 +91,7 @@ func init() {
 	timeConversionCmd.Flags().StringVar(&timeConversionFlags.LoCodeFrom, "lo_from", "", "LO code from")
 	timeConversionCmd.Flags().StringVar(&timeConversionFlags.LoCodeTo, "lo_to", "", "LO code to")
 	timeConversionCmd.Flags().StringVar(&timeConversionFlags.Time, "time", "", "Time")
-	timeConversionCmd.Flags().StringVar(&timeConversionFlags.Output, "output", "pretty", "Output format: pretty, raw, table, yaml")
+	addOutputFlag(timeConversionCmd, &timeConversionFlags.Output)
 
 	rootCmd.AddCommand(timeConversionCmd)
 }
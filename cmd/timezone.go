@@ -1,19 +1,12 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 
+	"github.com/IPGeolocation/cli/v2/client"
 	"github.com/IPGeolocation/cli/v2/internal/common"
-	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
-
-	encoding "net/url"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var timezoneFlags common.TimezoneFlags
@@ -54,87 +47,27 @@ Examples:
 Note: 
   - You must have a valid API key configured using: ipgeolocation config --apikey=<your-key>
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.Load()
-		if err != nil || cfg.ApiKey == "" {
-			fmt.Println("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
-			return
-		}
-
-		baseURL := "https://api.ipgeolocation.io/v3/timezone"
-		url := baseURL + "?apiKey=" + cfg.ApiKey
-		if timezoneFlags.IP != "" {
-			url += "&ip=" + timezoneFlags.IP
-		}
-
-		if timezoneFlags.IataCode != "" {
-			url += "&iata_code=" + timezoneFlags.IataCode
-		}
-
-		if timezoneFlags.IcaoCode != "" {
-			url += "&icao_code=" + timezoneFlags.IcaoCode
-		}
-
-		if timezoneFlags.LoCode != "" {
-			url += "&lo_code=" + timezoneFlags.LoCode
-		}
-
-		if timezoneFlags.Language != "" {
-			url += "&lang=" + timezoneFlags.Language
-		}
-
-		if timezoneFlags.Tz != "" {
-			url += "&tz=" + timezoneFlags.Tz
-		}
-
-		if timezoneFlags.Location != "" {
-			url += "&location=" + encoding.QueryEscape(timezoneFlags.Location)
-		}
-
-		if timezoneFlags.Latitude != 0 {
-			url += "&lat=" + fmt.Sprintf("%f", timezoneFlags.Latitude)
-		}
-
-		if timezoneFlags.Longitude != 0 {
-			url += "&long=" + fmt.Sprintf("%f", timezoneFlags.Longitude)
-		}
-
-		resp, err := http.Get(url)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient()
 		if err != nil {
-			fmt.Println("Error fetching timezone info:", err)
-			return
+			return err
 		}
-		defer resp.Body.Close()
 
-		body, _ := io.ReadAll(resp.Body)
-
-		if resp.StatusCode != 200 {
-			fmt.Printf("Error: %s\n", string(body))
-			return
-		}
-
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			fmt.Println("Error parsing JSON:", err)
-			return
-		}
-
-		switch timezoneFlags.Output {
-		case "raw":
-			fmt.Println(string(body))
-		case "table":
-			utils.PrintAsTable(result, 0)
-		case "yaml":
-			yamlData, err := yaml.Marshal(result)
-			if err != nil {
-				fmt.Println("Error converting to YAML:", err)
-				return
-			}
-			fmt.Println(string(yamlData))
-		default:
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(pretty))
+		resp, err := c.Timezone(cmd.Context(), client.TimezoneOptions{
+			IP:        timezoneFlags.IP,
+			Tz:        timezoneFlags.Tz,
+			Location:  timezoneFlags.Location,
+			Latitude:  timezoneFlags.Latitude,
+			Longitude: timezoneFlags.Longitude,
+			IataCode:  timezoneFlags.IataCode,
+			IcaoCode:  timezoneFlags.IcaoCode,
+			LoCode:    timezoneFlags.LoCode,
+			Language:  timezoneFlags.Language,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch timezone info: %w", err)
 		}
+		return printResponse(timezoneFlags.Output, "timezone", resp.Body)
 	},
 }
 
@@ -148,7 +81,7 @@ func init() {
 	timezoneCmd.Flags().StringVar(&timezoneFlags.IcaoCode, "icao", "", "ICAO code (e.g. KATL)")
 	timezoneCmd.Flags().StringVar(&timezoneFlags.LoCode, "lo", "", "LO code (e.g. DEBER)")
 	timezoneCmd.Flags().StringVar(&timezoneFlags.Language, "lang", "", "Language code (e.g. en)")
-	timezoneCmd.Flags().StringVar(&timezoneFlags.Output, "output", "pretty", "Output format: pretty, raw, table, yaml")
+	addOutputFlag(timezoneCmd, &timezoneFlags.Output)
 
 	rootCmd.AddCommand(timezoneCmd)
 }
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/IPGeolocation/cli/v2/internal/config"
+	"github.com/IPGeolocation/cli/v2/internal/usage"
+
+	"github.com/spf13/cobra"
+)
+
+var usageFlags struct {
+	By    string
+	Since string
+}
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Summarize the API requests and credits spent by this CLI",
+	Long: `The 'usage' command summarizes the local usage ledger, which records every command that sent
+requests to the API: when it ran, with which profile and how many requests it sent. The last
+credit, quota and rate limit headers returned by the API are kept too.
+
+The API does not document a header giving the credits a request cost, so credits are only
+counted for responses that carry an X-Credits-Charged header. Without one they are unknown: a
+credit total is shown as "unknown" when no command in it reported credits, and marked with *
+when some did not.
+
+Answers from the response cache, --offline and --replay cost nothing and are not recorded.
+
+Use the global --show-cost flag to print the credits charged by a single command.
+
+Examples:
+
+  # Credits per day over the last 30 days
+  ipgeolocation usage
+
+  # Credits per command this week, for one profile
+  ipgeolocation usage --by command --since 7d --profile work
+
+  # Start over
+  ipgeolocation usage clear`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch usageFlags.By {
+		case usage.ByDay, usage.ByCommand, usage.ByProfile:
+		default:
+			return usageErrorf("--by must be day, command or profile, got %q", usageFlags.By)
+		}
+		since, err := parseSince(usageFlags.Since, time.Now())
+		if err != nil {
+			return &usageError{msg: err.Error()}
+		}
+
+		records, err := usageLedger().Read(since)
+		if err != nil {
+			return fmt.Errorf("failed to read usage ledger: %w", err)
+		}
+		if rootCmd.PersistentFlags().Changed("profile") {
+			records = filterProfile(records, profileLabel(globalFlags.Profile))
+		}
+		if len(records) == 0 {
+			fmt.Printf("No API usage recorded since %s.\n", since.Format("2006-01-02"))
+			return nil
+		}
+
+		requests, credits, unreported := 0, 0.0, 0
+		for _, r := range records {
+			requests += r.Requests
+			credits += r.Credits
+			if !r.CreditsReported {
+				unreported++
+			}
+		}
+		fmt.Printf("📊 Since %s: %d %s, %d %s, credits: %s\n\n", since.Format("2006-01-02"),
+			len(records), plural(len(records), "command"), requests, plural(requests, "request"),
+			formatCredits(credits, unreported, len(records)))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "%s\tCOMMANDS\tREQUESTS\tCREDITS\n", strings.ToUpper(usageFlags.By))
+		for _, t := range usage.Summarize(records, usageFlags.By) {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", t.Key, t.Invocations, t.Requests, formatCredits(t.Credits, t.Unreported, t.Invocations))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		printQuota(records)
+		return nil
+	},
+}
+
+var usageClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete the usage ledger",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := usageLedger().Clear(); err != nil {
+			return fmt.Errorf("failed to delete usage ledger: %w", err)
+		}
+		fmt.Println("✅ Usage ledger cleared.")
+		return nil
+	},
+}
+
+// usageLedger returns the ledger kept next to the config file.
+func usageLedger() *usage.Ledger {
+	return &usage.Ledger{Path: filepath.Join(config.Dir(), "usage.jsonl")}
+}
+
+// profileLabel is how a profile is named in the ledger.
+func profileLabel(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// recordUsage adds the requests metered during the command to the ledger
+// and, with --show-cost, reports their cost. Failing to write the ledger
+// never fails the command.
+func recordUsage(cmd *cobra.Command) {
+	if usageMeter == nil {
+		if globalFlags.ShowCost {
+			fmt.Fprintln(stderr, "💳 Credits charged: 0 (no request reached the API)")
+		}
+		return
+	}
+	r := usageMeter.Record()
+	if globalFlags.ShowCost {
+		showCost(r)
+	}
+	if r.Requests == 0 {
+		return
+	}
+
+	r.Time = time.Now().UTC()
+	r.Profile = profileLabel(usageProfile)
+	r.Command = strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" ")
+	if err := usageLedger().Append(r); err != nil {
+		verbosef("failed to update usage ledger: %v", err)
+	}
+}
+
+// showCost prints the credits charged for the command to stderr.
+func showCost(r usage.Record) {
+	switch {
+	case r.Requests == 0:
+		fmt.Fprintln(stderr, "💳 Credits charged: 0 (no request reached the API)")
+	case !r.CreditsReported:
+		fmt.Fprintf(stderr, "💳 Credits charged: unknown; the API did not report the cost of %d %s\n", r.Requests, plural(r.Requests, "request"))
+	default:
+		fmt.Fprintf(stderr, "💳 Credits charged: %s for %d %s\n", strconv.FormatFloat(r.Credits, 'f', -1, 64), r.Requests, plural(r.Requests, "request"))
+	}
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// formatCredits prints a credit total, marking it with * when some of the
+// invocations it covers reported no credits, or as unknown when none did.
+func formatCredits(credits float64, unreported, invocations int) string {
+	if unreported == invocations {
+		return "unknown"
+	}
+	s := strconv.FormatFloat(credits, 'f', -1, 64)
+	if unreported > 0 {
+		s += "*"
+	}
+	return s
+}
+
+func filterProfile(records []usage.Record, profile string) []usage.Record {
+	var kept []usage.Record
+	for _, r := range records {
+		if r.Profile == profile {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// printQuota shows the latest credit, quota and rate limit headers the API
+// returned for each profile.
+func printQuota(records []usage.Record) {
+	latest := map[string]usage.Record{}
+	for _, r := range records {
+		if len(r.Quota) > 0 {
+			latest[r.Profile] = r
+		}
+	}
+	if len(latest) == 0 {
+		return
+	}
+
+	profiles := make([]string, 0, len(latest))
+	for profile := range latest {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+
+	fmt.Println("\nLast reported by the API:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, profile := range profiles {
+		r := latest[profile]
+		names := make([]string, 0, len(r.Quota))
+		for name := range r.Quota {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "  %s\t%s:\t%s\t(%s)\n", profile, name, r.Quota[name], r.Time.Local().Format("2006-01-02 15:04"))
+		}
+	}
+	w.Flush()
+}
+
+// parseSince parses --since: a number of days such as 30d, a duration such
+// as 12h, or a date such as 2025-01-31.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("--since must be a number of days (30d), a duration (12h) or a date (2025-01-31), got %q", value)
+}
+
+func init() {
+	usageCmd.Flags().StringVar(&usageFlags.By, "by", usage.ByDay, "Group usage by day, command or profile")
+	usageCmd.Flags().StringVar(&usageFlags.Since, "since", "30d", "Only include usage since this many days (30d), this long ago (12h) or this date (2025-01-31)")
+
+	usageCmd.AddCommand(usageClearCmd)
+	rootCmd.AddCommand(usageCmd)
+}
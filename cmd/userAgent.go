@@ -4,15 +4,11 @@ Copyright © 2026 IPGeolocation.io <support@ipgeolocation.io>
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/IPGeolocation/cli/v2/internal/common"
-	"github.com/IPGeolocation/cli/v2/internal/config"
-	"github.com/IPGeolocation/cli/v2/internal/utils"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var userAgentFlags common.ParseUserAgentFlags
@@ -29,60 +25,27 @@ Examples:
   ipgeolocation parse-user-agent --user-agent "<UA>" --output yaml
   ipgeolocation parse-user-agent --user-agent "<UA>" --output table
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.Load()
-		if err != nil || cfg.ApiKey == "" {
-			fmt.Println("API key not found. Please run: ipgeolocation config --apikey=<your-key>")
-			return
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient()
+		if err != nil {
+			return err
 		}
 
 		if userAgentFlags.UserAgent == "" {
-			fmt.Println("Please provide a user agent string using --user-agent")
-			return
+			return usageErrorf("please provide a user agent string using --user-agent")
 		}
-		baseURL := "https://api.ipgeolocation.io/v3/user-agent"
-		url := baseURL + "?apiKey=" + cfg.ApiKey
 
-		payload := map[string]interface{}{
-			"uaString": userAgentFlags.UserAgent,
-		}
-		body, err := utils.PostJSON(url, payload, map[string]string{
-			"Content-Type": "application/json",
-		})
+		resp, err := c.ParseUserAgent(cmd.Context(), userAgentFlags.UserAgent)
 		if err != nil {
-			fmt.Println("Error fetching user agent info:", err)
-			return
-		}
-
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			fmt.Println("Error parsing JSON:", err)
-			return
+			return fmt.Errorf("failed to fetch user agent info: %w", err)
 		}
-
-		switch userAgentFlags.Output {
-		case "raw":
-			fmt.Println(string(body))
-		case "table":
-			utils.PrintAsTable(result, 0)
-		case "yaml":
-			yamlData, err := yaml.Marshal(result)
-			if err != nil {
-				fmt.Println("Error converting to YAML:", err)
-				return
-			}
-			fmt.Println(string(yamlData))
-		default:
-			pretty, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(pretty))
-		}
-
+		return printResponse(userAgentFlags.Output, "user-agent", resp.Body)
 	},
 }
 
 func init() {
 	userAgentCmd.Flags().StringVar(&userAgentFlags.UserAgent, "user-agent", "", "User Agent")
-	userAgentCmd.Flags().StringVar(&userAgentFlags.Output, "output", "", "Output format:  raw, table, yaml")
+	addOutputFlag(userAgentCmd, &userAgentFlags.Output)
 	rootCmd.AddCommand(userAgentCmd)
 
 }
@@ -0,0 +1,203 @@
+// Package cache keeps API responses on disk so that repeated lookups do not
+// spend credits.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Entry is a cached response.
+type Entry struct {
+	Key       string      `json:"key"`
+	Endpoint  string      `json:"endpoint"`
+	StoredAt  time.Time   `json:"stored_at"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	Status    int         `json:"status"`
+	Header    http.Header `json:"header,omitempty"`
+	Body      []byte      `json:"body"`
+}
+
+// Expired reports whether the entry is past its TTL at now.
+func (e *Entry) Expired(now time.Time) bool {
+	return !now.Before(e.ExpiresAt)
+}
+
+// Age returns how long ago the entry was stored.
+func (e *Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.StoredAt)
+}
+
+// Store is a directory of cache entries, one file per key.
+type Store struct {
+	Dir string
+}
+
+// DefaultDir returns the cache directory under the user cache directory,
+// e.g. ~/.cache/ipgeolocation on Linux.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ipgeolocation"), nil
+}
+
+// ErrNotFound is returned by Get when no entry exists for a key.
+var ErrNotFound = errors.New("not in cache")
+
+const entrySuffix = ".json"
+
+func (s *Store) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:])+entrySuffix)
+}
+
+// Get returns the entry for key, expired or not.
+func (s *Store) Get(key string) (*Entry, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Key != key {
+		// A corrupt file or a hash collision; treat it as a miss.
+		return nil, ErrNotFound
+	}
+	return &e, nil
+}
+
+// Put stores e under e.Key, replacing any previous entry.
+func (s *Store) Put(e *Entry) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that concurrent readers never
+	// see a partial entry.
+	tmp, err := os.CreateTemp(s.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(e.Key))
+}
+
+// Stats summarizes the contents of a Store.
+type Stats struct {
+	Entries    int
+	Expired    int
+	Bytes      int64
+	ByEndpoint map[string]int
+	Oldest     time.Time
+	Newest     time.Time
+}
+
+// Stats reads every entry and summarizes them.
+func (s *Store) Stats(now time.Time) (Stats, error) {
+	stats := Stats{ByEndpoint: map[string]int{}}
+	err := s.walk(func(path string, info os.FileInfo, e *Entry) error {
+		stats.Entries++
+		stats.Bytes += info.Size()
+		if e == nil {
+			return nil
+		}
+		if e.Expired(now) {
+			stats.Expired++
+		}
+		stats.ByEndpoint[e.Endpoint]++
+		if stats.Oldest.IsZero() || e.StoredAt.Before(stats.Oldest) {
+			stats.Oldest = e.StoredAt
+		}
+		if e.StoredAt.After(stats.Newest) {
+			stats.Newest = e.StoredAt
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// Prune removes expired and unreadable entries and returns how many were
+// removed.
+func (s *Store) Prune(now time.Time) (int, error) {
+	removed := 0
+	err := s.walk(func(path string, info os.FileInfo, e *Entry) error {
+		if e != nil && !e.Expired(now) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+// Clear removes every entry and returns how many were removed.
+func (s *Store) Clear() (int, error) {
+	removed := 0
+	err := s.walk(func(path string, info os.FileInfo, e *Entry) error {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+// walk calls fn for every entry file. e is nil when the file cannot be
+// parsed. A missing directory is an empty cache.
+func (s *Store) walk(fn func(path string, info os.FileInfo, e *Entry) error) error {
+	files, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), entrySuffix) {
+			continue
+		}
+		path := filepath.Join(s.Dir, f.Name())
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		var e *Entry
+		if data, err := os.ReadFile(path); err == nil {
+			var parsed Entry
+			if json.Unmarshal(data, &parsed) == nil {
+				e = &parsed
+			}
+		}
+		if err := fn(path, info, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
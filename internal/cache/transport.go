@@ -0,0 +1,277 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderCache is set to "HIT" on responses served from the cache.
+const HeaderCache = "X-Ipgeolocation-Cache"
+
+// DefaultTTLs are how long responses of each endpoint stay fresh. Data
+// that rarely changes is kept for long; responses holding the current time
+// only briefly. Endpoints missing here are not cached.
+var DefaultTTLs = map[string]time.Duration{
+	"ipgeo":                24 * time.Hour,
+	"ipgeo-bulk":           24 * time.Hour,
+	"security":             6 * time.Hour,
+	"security-bulk":        6 * time.Hour,
+	"asn":                  7 * 24 * time.Hour,
+	"abuse":                7 * 24 * time.Hour,
+	"timezone":             time.Minute,
+	"timezone/convert":     time.Minute,
+	"astronomy":            time.Hour,
+	"astronomy/timeSeries": 24 * time.Hour,
+	"user-agent":           30 * 24 * time.Hour,
+	"user-agent-bulk":      30 * 24 * time.Hour,
+}
+
+// targetParams lists, for the endpoints that look up the caller's own
+// address when a request names nothing else, the query parameters naming
+// what to look up. Every group must have one of its parameters set; the
+// time conversion endpoint needs both ends. Requests naming nothing are
+// never cached, since the cache key does not identify the caller: after a
+// change of network, the cached answer would describe the old one.
+var targetParams = map[string][][]string{
+	"ipgeo":                {{"ip"}},
+	"security":             {{"ip"}},
+	"asn":                  {{"ip", "asn"}},
+	"abuse":                {{"ip"}},
+	"timezone":             {{"ip", "tz", "location", "lat", "iata_code", "icao_code", "lo_code"}},
+	"astronomy":            {{"ip", "location", "lat"}},
+	"astronomy/timeSeries": {{"ip", "location", "lat"}},
+	"timezone/convert": {
+		{"tz_from", "location_from", "lat_from", "iata_from", "icao_from", "locode_from"},
+		{"tz_to", "location_to", "lat_to", "iata_to", "icao_to", "locode_to"},
+	},
+}
+
+// namesTarget reports whether a request to endpoint with query names what
+// to look up, rather than falling back to the caller's address.
+func namesTarget(endpoint string, query url.Values) bool {
+	for _, group := range targetParams[endpoint] {
+		found := false
+		for _, name := range group {
+			if query.Get(name) != "" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// listParams are query parameters holding comma separated lists whose
+// order does not change the response.
+var listParams = map[string]bool{"include": true, "excludes": true, "fields": true}
+
+// Transport is an http.RoundTripper serving successful API responses from
+// a Store while they are fresh and storing new ones.
+type Transport struct {
+	// Base sends requests that are not served from the cache.
+	// http.DefaultTransport is used when nil.
+	Base  http.RoundTripper
+	Store *Store
+	// BaseURL is the API root; endpoints are request paths relative to it.
+	BaseURL string
+	// TTLs maps endpoints to how long their responses stay fresh.
+	TTLs map[string]time.Duration
+	// Refresh skips lookups but still stores the fresh responses.
+	Refresh bool
+	// Offline serves every request from the store, expired or not, and
+	// fails with ErrOfflineMiss instead of using Base.
+	Offline bool
+	// OnHit, when set, is called for each response served from the store.
+	OnHit func(endpoint string, age time.Duration, expired bool)
+	// Logf, when set, receives a line for each hit and store.
+	Logf func(format string, args ...interface{})
+}
+
+// ErrOfflineMiss is returned in offline mode for requests not in the cache.
+var ErrOfflineMiss = errors.New("not in the response cache, and offline mode is on")
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := t.endpoint(req.URL)
+	callerLookup := !namesTarget(endpoint, req.URL.Query())
+	if t.Offline {
+		if callerLookup {
+			return nil, ErrOfflineMiss
+		}
+		return t.offline(req, endpoint)
+	}
+
+	ttl := t.TTLs[endpoint]
+	if ttl <= 0 || (req.Method != http.MethodGet && req.Method != http.MethodPost) {
+		return t.base().RoundTrip(req)
+	}
+	if callerLookup {
+		t.logf("not caching %s: it looks up this machine's own address", endpoint)
+		return t.base().RoundTrip(req)
+	}
+
+	key, err := Key(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !t.Refresh {
+		if e, err := t.Store.Get(key); err == nil && !e.Expired(now) {
+			return t.hit(req, endpoint, e, now), nil
+		}
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Entry{
+		Key:       key,
+		Endpoint:  endpoint,
+		StoredAt:  now,
+		ExpiresAt: now.Add(ttl),
+		Status:    resp.StatusCode,
+		Header:    resp.Header.Clone(),
+		Body:      body,
+	}
+	if err := t.Store.Put(e); err != nil {
+		// A broken cache must not break lookups.
+		t.logf("failed to cache response for %s: %v", endpoint, err)
+	} else {
+		t.logf("cached %s response for %s", endpoint, ttl)
+	}
+	return resp, nil
+}
+
+func (t *Transport) offline(req *http.Request, endpoint string) (*http.Response, error) {
+	key, err := Key(req)
+	if err != nil {
+		return nil, err
+	}
+	e, err := t.Store.Get(key)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrOfflineMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t.hit(req, endpoint, e, time.Now()), nil
+}
+
+func (t *Transport) hit(req *http.Request, endpoint string, e *Entry, now time.Time) *http.Response {
+	age := e.Age(now)
+	t.logf("cache hit for %s (stored %s ago)", endpoint, age.Round(time.Second))
+	if t.OnHit != nil {
+		t.OnHit(endpoint, age, e.Expired(now))
+	}
+	return e.Response(req, now)
+}
+
+// Response rebuilds an HTTP response for req from the entry.
+func (e *Entry) Response(req *http.Request, now time.Time) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Age", strconv.Itoa(int(e.Age(now).Seconds())))
+	header.Set(HeaderCache, "HIT")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *Transport) logf(format string, args ...interface{}) {
+	if t.Logf != nil {
+		t.Logf(format, args...)
+	}
+}
+
+// endpoint returns the path of u relative to the API root.
+func (t *Transport) endpoint(u *url.URL) string {
+	basePath := ""
+	if base, err := url.Parse(t.BaseURL); err == nil {
+		basePath = strings.TrimRight(base.Path, "/")
+	}
+	return strings.Trim(strings.TrimPrefix(u.Path, basePath), "/")
+}
+
+// Key identifies a request in the cache: the method, the URL without the
+// API key and with its query normalized, and the body.
+func Key(req *http.Request) (string, error) {
+	query := req.URL.Query()
+	query.Del("apiKey")
+	for name, values := range query {
+		if listParams[name] {
+			for i, v := range values {
+				values[i] = normalizeList(v)
+			}
+		}
+	}
+
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		body, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	u := *req.URL
+	u.RawQuery = query.Encode()
+	u.Fragment = ""
+	return req.Method + " " + u.String() + "\n" + string(body), nil
+}
+
+// normalizeList sorts and dedupes a comma separated list.
+func normalizeList(list string) string {
+	seen := map[string]bool{}
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" && !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
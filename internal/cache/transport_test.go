@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRequest(t *testing.T, method, rawURL, body string) *http.Request {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, rawURL, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestKey(t *testing.T) {
+	const base = "https://api.example.com/v3/"
+	tests := []struct {
+		name string
+		a, b string // URLs of two GET requests
+		same bool
+	}{
+		{"API key ignored", base + "ipgeo?ip=8.8.8.8&apiKey=k-1", base + "ipgeo?ip=8.8.8.8&apiKey=k-2", true},
+		{"API key absent", base + "ipgeo?ip=8.8.8.8&apiKey=k-1", base + "ipgeo?ip=8.8.8.8", true},
+		{"parameter order", base + "ipgeo?ip=8.8.8.8&lang=de", base + "ipgeo?lang=de&ip=8.8.8.8", true},
+		{"list order", base + "ipgeo?ip=8.8.8.8&fields=location,asn", base + "ipgeo?ip=8.8.8.8&fields=asn,location", true},
+		{"list spaces and duplicates", base + "ipgeo?ip=8.8.8.8&include=security,%20hostname,security", base + "ipgeo?ip=8.8.8.8&include=hostname,security", true},
+		{"excludes normalized", base + "ipgeo?ip=8.8.8.8&excludes=b,a", base + "ipgeo?ip=8.8.8.8&excludes=a,b", true},
+		{"fragment ignored", base + "ipgeo?ip=8.8.8.8#x", base + "ipgeo?ip=8.8.8.8", true},
+		{"other lists keep order", base + "timezone?location=Berlin,Germany", base + "timezone?location=Germany,Berlin", false},
+		{"different IP", base + "ipgeo?ip=8.8.8.8", base + "ipgeo?ip=1.1.1.1", false},
+		{"different endpoint", base + "ipgeo?ip=8.8.8.8", base + "security?ip=8.8.8.8", false},
+		{"different API URL", base + "ipgeo?ip=8.8.8.8", "http://127.0.0.1:8080/v3/ipgeo?ip=8.8.8.8", false},
+		{"different list", base + "ipgeo?ip=8.8.8.8&fields=asn", base + "ipgeo?ip=8.8.8.8&fields=asn,location", false},
+	}
+	for _, tt := range tests {
+		a, err := Key(newRequest(t, http.MethodGet, tt.a, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Key(newRequest(t, http.MethodGet, tt.b, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (a == b) != tt.same {
+			t.Errorf("%s: keys %q and %q, want same = %v", tt.name, a, b, tt.same)
+		}
+		if strings.Contains(a, "k-1") {
+			t.Errorf("%s: key %q contains the API key", tt.name, a)
+		}
+	}
+
+	// The body of bulk requests is part of the key, and reading it leaves
+	// the request able to send it.
+	post := func(body string) string {
+		req := newRequest(t, http.MethodPost, base+"ipgeo-bulk?apiKey=k-1", body)
+		key, err := Key(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sent, _ := io.ReadAll(req.Body); string(sent) != body {
+			t.Errorf("Key consumed the body: %q left", sent)
+		}
+		return key
+	}
+	if post(`{"ips":["8.8.8.8"]}`) == post(`{"ips":["1.1.1.1"]}`) {
+		t.Error("bulk requests with different bodies share a key")
+	}
+	if post(`{"ips":["8.8.8.8"]}`) == mustKey(t, newRequest(t, http.MethodGet, base+"ipgeo-bulk", "")) {
+		t.Error("GET and POST share a key")
+	}
+}
+
+func mustKey(t *testing.T, req *http.Request) string {
+	t.Helper()
+	key, err := Key(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestNamesTarget(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		query    string
+		want     bool
+	}{
+		{"ipgeo", "ip=8.8.8.8", true},
+		{"ipgeo", "", false},
+		{"ipgeo", "ip=", false},
+		{"ipgeo", "lang=de&fields=location", false},
+		{"security", "", false},
+		{"abuse", "ip=8.8.8.8", true},
+		{"asn", "asn=AS15169", true},
+		{"asn", "ip=8.8.8.8", true},
+		{"asn", "", false},
+		{"timezone", "tz=Europe/Berlin", true},
+		{"timezone", "lat=52.5&long=13.4", true},
+		{"timezone", "lang=de", false},
+		{"astronomy", "location=Berlin", true},
+		{"astronomy", "date=2025-06-21", false},
+		{"astronomy/timeSeries", "", false},
+		{"timezone/convert", "tz_from=UTC&tz_to=Asia/Tokyo", true},
+		{"timezone/convert", "location_from=Berlin&iata_to=NRT", true},
+		{"timezone/convert", "tz_from=UTC", false},
+		{"timezone/convert", "tz_to=UTC", false},
+		// Endpoints that always name what they look up.
+		{"ipgeo-bulk", "", true},
+		{"user-agent", "", true},
+	}
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := namesTarget(tt.endpoint, query); got != tt.want {
+			t.Errorf("namesTarget(%q, %q) = %v, want %v", tt.endpoint, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		io.WriteString(w, `{"ip":"`+r.URL.Query().Get("ip")+`"}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	transport := &Transport{
+		Store:   &Store{Dir: dir},
+		BaseURL: srv.URL + "/v3",
+		TTLs:    DefaultTTLs,
+	}
+	client := &http.Client{Transport: transport}
+	get := func(query string) (*http.Response, error) {
+		resp, err := client.Get(srv.URL + "/v3/ipgeo?" + query)
+		if err == nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	// A lookup naming its IP is cached, without its API key.
+	for i := 0; i < 2; i++ {
+		resp, err := get("ip=8.8.8.8&apiKey=k-secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hit := resp.Header.Get(HeaderCache) == "HIT"; hit != (i == 1) {
+			t.Errorf("request %d: cache hit = %v", i+1, hit)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("server saw %d requests for a cached lookup, want 1", hits)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, f := range files {
+		if data, _ := os.ReadFile(f); bytes.Contains(data, []byte("k-secret")) {
+			t.Errorf("%s contains the API key", f)
+		}
+	}
+
+	// A lookup of the caller's own address always goes to the API.
+	atomic.StoreInt32(&hits, 0)
+	for i := 0; i < 2; i++ {
+		resp, err := get("apiKey=k-secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Header.Get(HeaderCache) != "" {
+			t.Errorf("self lookup %d served from the cache", i+1)
+		}
+	}
+	if hits != 2 {
+		t.Errorf("server saw %d self lookups, want 2", hits)
+	}
+	if after, _ := filepath.Glob(filepath.Join(dir, "*")); len(after) != len(files) {
+		t.Errorf("self lookups added %d cache entries", len(after)-len(files))
+	}
+
+	// Offline, the cached lookup is served and anything else misses.
+	transport.Offline = true
+	atomic.StoreInt32(&hits, 0)
+	var ages []time.Duration
+	transport.OnHit = func(endpoint string, age time.Duration, expired bool) { ages = append(ages, age) }
+	if _, err := get("ip=8.8.8.8"); err != nil {
+		t.Errorf("offline cached lookup: %v", err)
+	}
+	for _, query := range []string{"", "ip=1.1.1.1"} {
+		if _, err := get(query); !errors.Is(err, ErrOfflineMiss) {
+			t.Errorf("offline lookup %q: got %v, want ErrOfflineMiss", query, err)
+		}
+	}
+	if hits != 0 || len(ages) != 1 {
+		t.Errorf("offline: %d requests sent, %d hits; want 0 and 1", hits, len(ages))
+	}
+}
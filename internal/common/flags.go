@@ -1,5 +1,41 @@
 package common
 
+import "time"
+
+// GlobalFlags holds the persistent flags shared by every command.
+type GlobalFlags struct {
+	APIKey       string
+	APIURL       string
+	Profile      string
+	Retries      int
+	RetryMaxWait time.Duration
+	Timeout      time.Duration
+	Verbose      bool
+
+	Debug          bool
+	DebugBodyLimit int
+	HARFile        string
+
+	Proxy              string
+	CACert             string
+	ClientCert         string
+	ClientKey          string
+	InsecureSkipVerify bool
+
+	NoCache bool
+	Refresh bool
+	Offline bool
+
+	RecordDir   string
+	ReplayDir   string
+	ReplayMatch string
+
+	Rate  float64
+	Burst int
+
+	ShowCost bool
+}
+
 type ASNFlags struct {
 	IP       string
 	ASN      string
@@ -84,6 +120,7 @@ type BulkIPSecurityFlags struct {
 	Output     string
 	OutputFile string
 	File       string
+	BatchSize  int
 }
 
 type ParseUserAgentFlags struct {
@@ -109,6 +146,7 @@ type BulkIpgeoFlags struct {
 	Output     string
 	File       string
 	OutputFile string
+	BatchSize  int
 }
 
 type ParseBulkUserAgentFlags struct {
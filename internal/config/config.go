@@ -4,43 +4,132 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
-
-	"github.com/IPGeolocation/cli/v2/internal/utils"
 )
 
 type Config struct {
+	// Version is the schema version of the file; see CurrentVersion.
+	Version int `json:"version"`
+
+	// ApiKey is encrypted with utils.EncryptString.
 	ApiKey string `json:"apikey"`
+	// KeyScheme is how API keys in this file are encrypted: "machine"
+	// (the default) or "passphrase". Change it with `config rekey`.
+	KeyScheme    string `json:"key_scheme,omitempty"`
+	ApiURL       string `json:"api_url,omitempty"`
+	Retries      *int   `json:"retries,omitempty"`
+	RetryMaxWait string `json:"retry_max_wait,omitempty"`
+	Timeout      string `json:"timeout,omitempty"`
+	Network
+	RateLimit
+
+	// CurrentProfile is used when no profile is selected with --profile or
+	// IPGEOLOCATION_PROFILE.
+	CurrentProfile string              `json:"current_profile,omitempty"`
+	Profiles       map[string]*Profile `json:"profiles,omitempty"`
+
+	Cache *CacheSettings `json:"cache,omitempty"`
+
+	// Defaults holds flag values used when a flag is not given on the
+	// command line, keyed by command name and then flag name. The
+	// DefaultsGlobal entry applies to every command that has the flag.
+	Defaults map[string]map[string]string `json:"defaults,omitempty"`
+}
+
+// CacheSettings configures the on-disk response cache.
+type CacheSettings struct {
+	// Dir overrides the cache directory under the user cache directory.
+	Dir      string `json:"dir,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+	// TTL overrides how long responses stay fresh, per endpoint, as
+	// durations such as "12h". "0s" turns caching off for an endpoint.
+	TTL map[string]string `json:"ttl,omitempty"`
+}
+
+// DefaultsGlobal is the Defaults key applying to all commands.
+const DefaultsGlobal = "global"
+
+// Profile is a named set of settings, e.g. one per API key.
+type Profile struct {
+	// ApiKey is encrypted with utils.EncryptString. It is only used when
+	// SecretStore is nil or selects the encrypted backend.
+	ApiKey      string       `json:"apikey,omitempty"`
+	SecretStore *SecretStore `json:"secret_store,omitempty"`
+	ApiURL      string       `json:"api_url,omitempty"`
+	Language    string       `json:"lang,omitempty"`
+	Output      string       `json:"output,omitempty"`
+	// Offline makes commands answer from the response cache only.
+	Offline bool `json:"offline,omitempty"`
+	Network
+	RateLimit
+}
+
+// Network holds the proxy and TLS settings, which can be set at the top
+// level and per profile. A profile's non-empty settings take precedence.
+type Network struct {
+	Proxy              string `json:"proxy,omitempty"`
+	CACert             string `json:"ca_cert,omitempty"`
+	ClientCert         string `json:"client_cert,omitempty"`
+	ClientKey          string `json:"client_key,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
 }
 
-func configPath() string {
+// RateLimit paces requests on the client side, at the top level and per
+// profile. A profile's non-zero settings take precedence.
+type RateLimit struct {
+	// Rate is the number of requests per second; 0 means no limit.
+	Rate  float64 `json:"rate,omitempty"`
+	Burst int     `json:"burst,omitempty"`
+}
+
+// SecretStore selects where a profile's API key is kept. See package
+// internal/secret for the available backends.
+type SecretStore struct {
+	Backend    string `json:"backend"`
+	Path       string `json:"path,omitempty"`
+	Command    string `json:"command,omitempty"`
+	SetCommand string `json:"set_command,omitempty"`
+	Service    string `json:"service,omitempty"`
+	Account    string `json:"account,omitempty"`
+}
+
+// Dir returns the directory holding the config file.
+func Dir() string {
 	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".ipgeolocation", "config.json")
+	return filepath.Join(home, ".ipgeolocation")
+}
+
+// Path returns the location of the config file.
+func Path() string {
+	return filepath.Join(Dir(), "config.json")
 }
 
+// Save writes cfg to the config file with the current schema version.
 func Save(cfg Config) error {
-	path := configPath()
+	cfg.Version = CurrentVersion
+	path := Path()
 	os.MkdirAll(filepath.Dir(path), 0755)
 	data, _ := json.MarshalIndent(cfg, "", "  ")
-	return os.WriteFile(path, data, 0600)
+	return writeFile(path, data)
 }
 
+// Load reads the config file, upgrading it to CurrentVersion first if it
+// was written by an older version. API keys are returned as stored; use a
+// secret store to read them.
 func Load() (Config, error) {
-	path := configPath()
+	path := Path()
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return Config{}, err
 	}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	data, err = migrate(path, data)
+	if err != nil {
 		return Config{}, err
 	}
 
-	if cfg.ApiKey != "" {
-		decrypted, err := utils.DecryptString(cfg.ApiKey)
-		if err == nil {
-			cfg.ApiKey = decrypted
-		}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, err
 	}
 
 	return cfg, nil
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CurrentVersion is the schema version written by Save.
+//
+// Version history:
+//
+//	1  {"apikey": ...} only, without a version field
+//	2  adds the version field, profiles, defaults, key_scheme and the
+//	   retry and timeout settings
+const CurrentVersion = 2
+
+// migrations[n] upgrades a raw version n config to version n+1. Add a
+// function here, and bump CurrentVersion, whenever a change to Config would
+// be misread by older code or needs existing files to be rewritten.
+var migrations = map[int]func(raw map[string]interface{}) error{
+	1: func(raw map[string]interface{}) error {
+		// Version 2 only added settings; existing keys keep their meaning.
+		return nil
+	},
+}
+
+// OnMigrate, when set, is called after a config file has been upgraded.
+var OnMigrate func(from, to int, backup string)
+
+// fileVersion returns the schema version of a raw config. Files written
+// before versioning have none and are version 1.
+func fileVersion(raw map[string]interface{}) (int, error) {
+	v, ok := raw["version"]
+	if !ok {
+		return 1, nil
+	}
+	n, ok := v.(float64)
+	if !ok || n != float64(int(n)) || n < 1 {
+		return 0, fmt.Errorf("invalid config version %v", v)
+	}
+	return int(n), nil
+}
+
+// migrate upgrades the config file at path to CurrentVersion if needed,
+// saving a copy of the original next to it first. It returns the data to
+// load.
+func migrate(path string, data []byte) ([]byte, error) {
+	return upgrade(path, data, CurrentVersion, migrations)
+}
+
+// upgrade applies steps to the config file at path to bring it to version
+// to. The original is kept in a backup file that is never overwritten, and
+// the upgraded file replaces it atomically.
+func upgrade(path string, data []byte, to int, steps map[int]func(raw map[string]interface{}) error) ([]byte, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	from, err := fileVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("config file version %d is newer than this version of ipgeolocation supports (%d); please upgrade", from, to)
+	}
+	if from == to {
+		return data, nil
+	}
+
+	for v := from; v < to; v++ {
+		step, ok := steps[v]
+		if !ok {
+			return nil, fmt.Errorf("no migration from config version %d", v)
+		}
+		if err := step(raw); err != nil {
+			return nil, fmt.Errorf("failed to migrate config from version %d: %w", v, err)
+		}
+		raw["version"] = v + 1
+	}
+
+	backup, err := writeBackup(path, from, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up config before migration: %w", err)
+	}
+	migrated, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(path, migrated); err != nil {
+		return nil, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+
+	if OnMigrate != nil {
+		OnMigrate(from, to, backup)
+	}
+	return migrated, nil
+}
+
+// writeBackup saves data as path.vN.bak, N being its version. If that file
+// already exists, from an earlier migration, a timestamp is added to the
+// name instead of replacing it. It returns the name of the backup.
+func writeBackup(path string, version int, data []byte) (string, error) {
+	names := []string{
+		fmt.Sprintf("%s.v%d.bak", path, version),
+		fmt.Sprintf("%s.v%d.%s.bak", path, version, time.Now().Format("20060102-150405")),
+	}
+	for _, name := range names {
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			os.Remove(name)
+			return "", err
+		}
+		if err := f.Close(); err != nil {
+			os.Remove(name)
+			return "", err
+		}
+		return name, nil
+	}
+	return "", fmt.Errorf("%s already exists", names[len(names)-1])
+}
+
+// writeFile replaces the file at path with data, writing to a temporary
+// file first so that a failure never leaves a partial config behind.
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
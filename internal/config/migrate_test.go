@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readJSON(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestMigrateFromVersion1(t *testing.T) {
+	const v1 = `{"apikey": "k-123"}`
+	path := writeConfig(t, v1)
+
+	var gotFrom, gotTo int
+	var gotBackup string
+	OnMigrate = func(from, to int, backup string) { gotFrom, gotTo, gotBackup = from, to, backup }
+	defer func() { OnMigrate = nil }()
+
+	data, err := migrate(path, []byte(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotFrom != 1 || gotTo != CurrentVersion || gotBackup != path+".v1.bak" {
+		t.Errorf("OnMigrate(%d, %d, %q); want (1, %d, %q)", gotFrom, gotTo, gotBackup, CurrentVersion, path+".v1.bak")
+	}
+	raw := readJSON(t, path)
+	if raw["version"] != float64(CurrentVersion) || raw["apikey"] != "k-123" {
+		t.Errorf("migrated file = %v", raw)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil || cfg.Version != CurrentVersion {
+		t.Errorf("returned data %s: version %d, %v", data, cfg.Version, err)
+	}
+	if backup, err := os.ReadFile(path + ".v1.bak"); err != nil || string(backup) != v1 {
+		t.Errorf("backup = %q, %v; want the original file", backup, err)
+	}
+
+	// Loading the migrated file again changes nothing.
+	again, err := migrate(path, data)
+	if err != nil || string(again) != string(data) {
+		t.Errorf("second migrate = %s, %v; want the data unchanged", again, err)
+	}
+}
+
+func TestMigrateChain(t *testing.T) {
+	var order []int
+	steps := map[int]func(raw map[string]interface{}) error{}
+	for v := 1; v <= 3; v++ {
+		v := v
+		steps[v] = func(raw map[string]interface{}) error {
+			order = append(order, v)
+			if version := raw["version"]; version != nil && fmt.Sprint(version) != fmt.Sprint(v) {
+				t.Errorf("step %d saw version %v", v, raw["version"])
+			}
+			if v == 2 {
+				raw["renamed"] = raw["old"]
+				delete(raw, "old")
+			}
+			return nil
+		}
+	}
+
+	path := writeConfig(t, `{"old": "x"}`)
+	if _, err := upgrade(path, []byte(`{"old": "x"}`), 4, steps); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Errorf("steps ran in order %v, want [1 2 3]", order)
+	}
+	raw := readJSON(t, path)
+	if raw["version"] != float64(4) || raw["renamed"] != "x" || raw["old"] != nil {
+		t.Errorf("migrated file = %v", raw)
+	}
+
+	// A gap in the chain, or a failing step, leaves the file alone.
+	path = writeConfig(t, `{"version": 2}`)
+	delete(steps, 3)
+	if _, err := upgrade(path, []byte(`{"version": 2}`), 4, steps); err == nil || !strings.Contains(err.Error(), "no migration from config version 3") {
+		t.Errorf("missing step: got %v", err)
+	}
+	steps[3] = func(map[string]interface{}) error { return errors.New("boom") }
+	if _, err := upgrade(path, []byte(`{"version": 2}`), 4, steps); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("failing step: got %v", err)
+	}
+	if raw := readJSON(t, path); raw["version"] != float64(2) {
+		t.Errorf("file changed by a failed migration: %v", raw)
+	}
+	assertNoTempFiles(t, filepath.Dir(path))
+}
+
+func TestMigrateRefusesNewerVersion(t *testing.T) {
+	newer := `{"version": 99, "apikey": "k-123"}`
+	path := writeConfig(t, newer)
+	_, err := migrate(path, []byte(newer))
+	if err == nil || !strings.Contains(err.Error(), "newer than this version") {
+		t.Fatalf("got %v, want a newer version error", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Errorf("file changed to %s", data)
+	}
+	if _, err := os.Stat(path + ".v99.bak"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("backup written for a refused migration: %v", err)
+	}
+
+	for _, bad := range []string{`{"version": 0}`, `{"version": 1.5}`, `{"version": "2"}`} {
+		if _, err := migrate(path, []byte(bad)); err == nil || !strings.Contains(err.Error(), "invalid config version") {
+			t.Errorf("%s: got %v, want an invalid version error", bad, err)
+		}
+	}
+}
+
+func TestMigrateKeepsExistingBackups(t *testing.T) {
+	const v1 = `{"apikey": "k-new"}`
+	path := writeConfig(t, v1)
+	const older = `{"apikey": "k-old"}`
+	if err := os.WriteFile(path+".v1.bak", []byte(older), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var backup string
+	OnMigrate = func(_, _ int, b string) { backup = b }
+	defer func() { OnMigrate = nil }()
+	if _, err := migrate(path, []byte(v1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(path + ".v1.bak"); string(data) != older {
+		t.Errorf("existing backup overwritten with %s", data)
+	}
+	if backup == path+".v1.bak" || !strings.HasPrefix(backup, path+".v1.") || !strings.HasSuffix(backup, ".bak") {
+		t.Fatalf("backup written to %q", backup)
+	}
+	if data, _ := os.ReadFile(backup); string(data) != v1 {
+		t.Errorf("new backup = %s, want the original file", data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("migrated config mode %04o, want 0600", info.Mode().Perm())
+	}
+	assertNoTempFiles(t, filepath.Dir(path))
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	matches, _ := filepath.Glob(filepath.Join(dir, ".config-*"))
+	if len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
@@ -0,0 +1,457 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/IPGeolocation/cli/v2/internal/cache"
+	"github.com/IPGeolocation/cli/v2/internal/secret"
+)
+
+// Problem is an issue found by Validate, located in the config file.
+type Problem struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+}
+
+// ValidateHooks lets callers check values whose rules live outside this
+// package. Nil hooks are skipped.
+type ValidateHooks struct {
+	// APIURL checks an api_url value.
+	APIURL func(value string) error
+	// Proxy checks a proxy value.
+	Proxy func(value string) error
+	// Default checks a defaults.<command>.<flag> entry, and a profile's
+	// output with the command "global".
+	Default func(command, flag, value string) error
+}
+
+// Validate reports syntax errors, unknown keys and invalid values in the
+// config file data, in file order.
+func Validate(data []byte, hooks ValidateHooks) []Problem {
+	root, err := parseNodes(data)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		var posErr *positionError
+		offset := int64(len(data))
+		if errors.As(err, &syntaxErr) && syntaxErr.Offset > 0 {
+			// Offset counts the offending byte as read.
+			offset = syntaxErr.Offset - 1
+		} else if errors.As(err, &posErr) {
+			offset = posErr.offset
+		}
+		line, col := lineColumn(data, offset)
+		return []Problem{{Line: line, Column: col, Message: err.Error()}}
+	}
+
+	v := &validator{data: data, hooks: hooks}
+	v.config(root)
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		a, b := v.problems[i], v.problems[j]
+		return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+	})
+	return v.problems
+}
+
+// positionError is a parse error at a known offset.
+type positionError struct {
+	offset int64
+	msg    string
+}
+
+func (e *positionError) Error() string {
+	return e.msg
+}
+
+// node is a JSON value together with where it starts in the file.
+type node struct {
+	offset  int64
+	value   interface{} // string, json.Number, bool or nil for scalars
+	isObj   bool
+	isArr   bool
+	members []member // for objects, in file order
+}
+
+type member struct {
+	key       string
+	keyOffset int64
+	value     *node
+}
+
+func parseNodes(data []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := parseNode(dec, data)
+	if err != nil {
+		return nil, err
+	}
+	offset := tokenStart(data, dec.InputOffset())
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &positionError{offset: offset, msg: "unexpected data after the top-level object"}
+	}
+	return n, nil
+}
+
+func parseNode(dec *json.Decoder, data []byte) (*node, error) {
+	start := tokenStart(data, dec.InputOffset())
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, errors.New("unexpected end of file")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n := &node{offset: start}
+	switch tok {
+	case json.Delim('{'):
+		n.isObj = true
+		for dec.More() {
+			keyStart := tokenStart(data, dec.InputOffset())
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := parseNode(dec, data)
+			if err != nil {
+				return nil, err
+			}
+			n.members = append(n.members, member{key: key.(string), keyOffset: keyStart, value: value})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case json.Delim('['):
+		n.isArr = true
+		for dec.More() {
+			if _, err := parseNode(dec, data); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	default:
+		n.value = tok
+	}
+	return n, nil
+}
+
+// tokenStart skips the whitespace and separators between the end of the
+// previous token and the start of the next one.
+func tokenStart(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// lineColumn converts a byte offset to a 1-based line and column.
+func lineColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line, col := 1, 1
+	for _, b := range data[:offset] {
+		if b == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+type validator struct {
+	data     []byte
+	hooks    ValidateHooks
+	problems []Problem
+}
+
+func (v *validator) addf(offset int64, format string, args ...interface{}) {
+	line, col := lineColumn(v.data, offset)
+	v.problems = append(v.problems, Problem{Line: line, Column: col, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) config(n *node) {
+	if !n.isObj {
+		v.addf(n.offset, "config must be a JSON object")
+		return
+	}
+
+	profiles := map[string]bool{}
+	for _, m := range n.members {
+		if m.key == "profiles" && m.value.isObj {
+			for _, p := range m.value.members {
+				profiles[p.key] = true
+			}
+		}
+	}
+
+	for _, m := range n.members {
+		switch m.key {
+		case "version":
+			if version, ok := v.integer("version", m.value); ok && (version < 1 || version > CurrentVersion) {
+				v.addf(m.value.offset, "version: unsupported version %d (this CLI supports up to %d)", version, CurrentVersion)
+			}
+		case "apikey", "api_url", "key_scheme", "retry_max_wait", "timeout", "current_profile":
+			s, ok := v.str(m.key, m.value)
+			if !ok || s == "" {
+				continue
+			}
+			switch m.key {
+			case "api_url":
+				v.apiURL(m.key, m.value, s)
+			case "key_scheme":
+				if err := secret.ValidateScheme(s); err != nil {
+					v.addf(m.value.offset, "%s: %v", m.key, err)
+				}
+			case "retry_max_wait", "timeout":
+				if d, err := time.ParseDuration(s); err != nil || d < 0 {
+					v.addf(m.value.offset, "%s: %q is not a valid duration, e.g. 30s or 1m", m.key, s)
+				}
+			case "current_profile":
+				if !profiles[s] {
+					v.addf(m.value.offset, "%s: profile %q is not defined", m.key, s)
+				}
+			}
+		case "proxy", "ca_cert", "client_cert", "client_key", "insecure_skip_verify":
+			v.network(m.key, m)
+		case "retries":
+			if retries, ok := v.integer(m.key, m.value); ok && retries < 0 {
+				v.addf(m.value.offset, "retries: must not be negative")
+			}
+		case "rate", "burst":
+			v.rateLimit(m.key, m)
+		case "profiles":
+			v.objectOf(m.key, m.value, v.profile)
+		case "defaults":
+			v.objectOf(m.key, m.value, v.defaults)
+		case "cache":
+			v.cache(m.key, m.value)
+		default:
+			v.unknown(m, "")
+		}
+	}
+}
+
+func (v *validator) profile(path string, n *node) {
+	for _, m := range n.members {
+		key := path + "." + m.key
+		switch m.key {
+		case "apikey", "lang":
+			v.str(key, m.value)
+		case "output":
+			if s, ok := v.str(key, m.value); ok && s != "" && v.hooks.Default != nil {
+				if err := v.hooks.Default(DefaultsGlobal, m.key, s); err != nil {
+					v.addf(m.value.offset, "%s: %v", key, err)
+				}
+			}
+		case "api_url":
+			if s, ok := v.str(key, m.value); ok && s != "" {
+				v.apiURL(key, m.value, s)
+			}
+		case "secret_store":
+			v.secretStore(key, m.value)
+		case "offline":
+			if _, ok := m.value.value.(bool); !ok {
+				v.addf(m.value.offset, "%s: must be true or false", key)
+			}
+		case "proxy", "ca_cert", "client_cert", "client_key", "insecure_skip_verify":
+			v.network(key, m)
+		case "rate", "burst":
+			v.rateLimit(key, m)
+		default:
+			v.unknown(m, path)
+		}
+	}
+}
+
+func (v *validator) cache(path string, n *node) {
+	if !n.isObj {
+		v.addf(n.offset, "%s: must be an object", path)
+		return
+	}
+	for _, m := range n.members {
+		key := path + "." + m.key
+		switch m.key {
+		case "dir":
+			v.str(key, m.value)
+		case "disabled":
+			if _, ok := m.value.value.(bool); !ok {
+				v.addf(m.value.offset, "%s: must be true or false", key)
+			}
+		case "ttl":
+			if !m.value.isObj {
+				v.addf(m.value.offset, "%s: must be an object", key)
+				continue
+			}
+			for _, ttl := range m.value.members {
+				ttlKey := key + "." + ttl.key
+				if _, ok := cache.DefaultTTLs[ttl.key]; !ok {
+					v.addf(ttl.keyOffset, "unknown endpoint %q in %s", ttl.key, key)
+					continue
+				}
+				if s, ok := v.str(ttlKey, ttl.value); ok {
+					if d, err := time.ParseDuration(s); err != nil || d < 0 {
+						v.addf(ttl.value.offset, "%s: %q is not a valid duration, e.g. 12h", ttlKey, s)
+					}
+				}
+			}
+		default:
+			v.unknown(m, path)
+		}
+	}
+}
+
+// network checks a proxy or TLS setting.
+func (v *validator) network(path string, m member) {
+	if m.key == "insecure_skip_verify" {
+		if _, ok := m.value.value.(bool); !ok {
+			v.addf(m.value.offset, "%s: must be true or false", path)
+		}
+		return
+	}
+	s, ok := v.str(path, m.value)
+	if !ok || s == "" {
+		return
+	}
+	if m.key == "proxy" {
+		if v.hooks.Proxy != nil {
+			if err := v.hooks.Proxy(s); err != nil {
+				v.addf(m.value.offset, "%s: %v", path, err)
+			}
+		}
+		return
+	}
+	if _, err := os.Stat(s); err != nil {
+		v.addf(m.value.offset, "%s: cannot read %s", path, s)
+	}
+}
+
+// rateLimit checks a rate or burst setting.
+func (v *validator) rateLimit(path string, m member) {
+	if m.key == "burst" {
+		if burst, ok := v.integer(path, m.value); ok && burst < 0 {
+			v.addf(m.value.offset, "%s: must not be negative", path)
+		}
+		return
+	}
+	num, ok := m.value.value.(json.Number)
+	rate, err := num.Float64()
+	if !ok || err != nil {
+		v.addf(m.value.offset, "%s: must be a number of requests per second", path)
+	} else if rate < 0 {
+		v.addf(m.value.offset, "%s: must not be negative", path)
+	}
+}
+
+func (v *validator) secretStore(path string, n *node) {
+	if !n.isObj {
+		v.addf(n.offset, "%s: must be an object", path)
+		return
+	}
+	hasBackend := false
+	for _, m := range n.members {
+		key := path + "." + m.key
+		switch m.key {
+		case "backend":
+			hasBackend = true
+			if s, ok := v.str(key, m.value); ok {
+				if err := secret.ValidateBackend(s); err != nil {
+					v.addf(m.value.offset, "%s: %v", key, err)
+				}
+			}
+		case "path", "command", "set_command", "service", "account":
+			v.str(key, m.value)
+		default:
+			v.unknown(m, path)
+		}
+	}
+	if !hasBackend {
+		v.addf(n.offset, "%s: missing backend", path)
+	}
+}
+
+func (v *validator) defaults(path string, n *node) {
+	command := path[len("defaults."):]
+	for _, m := range n.members {
+		key := path + "." + m.key
+		s, ok := v.str(key, m.value)
+		if !ok || v.hooks.Default == nil {
+			continue
+		}
+		if err := v.hooks.Default(command, m.key, s); err != nil {
+			v.addf(m.keyOffset, "%s: %v", key, err)
+		}
+	}
+}
+
+// objectOf checks that n is an object of objects and calls check on each.
+func (v *validator) objectOf(path string, n *node, check func(path string, n *node)) {
+	if !n.isObj {
+		v.addf(n.offset, "%s: must be an object", path)
+		return
+	}
+	for _, m := range n.members {
+		key := path + "." + m.key
+		if !m.value.isObj {
+			v.addf(m.value.offset, "%s: must be an object", key)
+			continue
+		}
+		check(key, m.value)
+	}
+}
+
+func (v *validator) str(path string, n *node) (string, bool) {
+	s, ok := n.value.(string)
+	if !ok {
+		v.addf(n.offset, "%s: must be a string", path)
+	}
+	return s, ok
+}
+
+func (v *validator) integer(path string, n *node) (int, bool) {
+	num, ok := n.value.(json.Number)
+	if ok {
+		if i, err := num.Int64(); err == nil {
+			return int(i), true
+		}
+	}
+	v.addf(n.offset, "%s: must be an integer", path)
+	return 0, false
+}
+
+func (v *validator) apiURL(path string, n *node, s string) {
+	if v.hooks.APIURL == nil {
+		return
+	}
+	if err := v.hooks.APIURL(s); err != nil {
+		v.addf(n.offset, "%s: %v", path, err)
+	}
+}
+
+func (v *validator) unknown(m member, path string) {
+	if path != "" {
+		v.addf(m.keyOffset, "unknown key %q in %s", m.key, path)
+		return
+	}
+	v.addf(m.keyOffset, "unknown key %q", m.key)
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidatePositions(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{
+			name:   "valid",
+			config: "{\n  \"version\": 2,\n  \"apikey\": \"k-123\",\n  \"retries\": 3\n}",
+		},
+		{
+			name:   "values and unknown keys",
+			config: "{\n  \"apikey\": \"k\",\n  \"retries\": -1,\n  \"colour\": true\n}",
+			want: []string{
+				`3:14: retries: must not be negative`,
+				`4:3: unknown key "colour"`,
+			},
+		},
+		{
+			name:   "nested",
+			config: "{\n  \"profiles\": {\n    \"work\": {\"offline\": \"yes\", \"lang\": 3}\n  },\n  \"current_profile\": \"home\"\n}",
+			want: []string{
+				`3:25: profiles.work.offline: must be true or false`,
+				`3:40: profiles.work.lang: must be a string`,
+				`5:22: current_profile: profile "home" is not defined`,
+			},
+		},
+		{
+			name:   "tabs count as one column",
+			config: "{\n\t\"rate\": \"fast\", \"burst\": 2.5\n}",
+			want: []string{
+				`2:10: rate: must be a number of requests per second`,
+				`2:27: burst: must be an integer`,
+			},
+		},
+		{
+			name:   "cache TTLs",
+			config: "{\n  \"cache\": {\"ttl\": {\"ipgeo\": \"1x\", \"nope\": \"1h\"}}\n}",
+			want: []string{
+				`2:30: cache.ttl.ipgeo: "1x" is not a valid duration, e.g. 12h`,
+				`2:36: unknown endpoint "nope" in cache.ttl`,
+			},
+		},
+		{
+			name:   "newer version",
+			config: `{"version": 9}`,
+			want:   []string{`1:13: version: unsupported version 9 (this CLI supports up to 2)`},
+		},
+		{
+			name:   "not an object",
+			config: `[]`,
+			want:   []string{`1:1: config must be a JSON object`},
+		},
+		{
+			name:   "missing comma",
+			config: "{\n  \"apikey\": \"k\"\n  \"retries\": 2\n}",
+			want:   []string{`3:3: invalid character '"' after object key:value pair`},
+		},
+		{
+			name:   "trailing comma",
+			config: "{\n  \"apikey\": \"k\",\n}",
+			want:   []string{`2:16: invalid character ',' looking for beginning of value`},
+		},
+		{
+			name:   "truncated",
+			config: `{"apikey": "k"`,
+			want:   []string{`1:14: unexpected end of JSON input`},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range Validate([]byte(tt.config), ValidateHooks{}) {
+			got = append(got, p.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateHooks(t *testing.T) {
+	config := "{\n  \"api_url\": \"ftp://x\",\n  \"defaults\": {\n    \"ipgeo\": {\"output\": \"html\"}\n  }\n}"
+	hooks := ValidateHooks{
+		APIURL: func(string) error { return errors.New("must be an absolute http(s) URL") },
+		Default: func(command, flag, value string) error {
+			if command != "ipgeo" || flag != "output" || value != "html" {
+				t.Errorf("Default(%q, %q, %q)", command, flag, value)
+			}
+			return errors.New("unknown output format")
+		},
+	}
+	want := []string{
+		`2:14: api_url: must be an absolute http(s) URL`,
+		`4:15: defaults.ipgeo.output: unknown output format`,
+	}
+	var got []string
+	for _, p := range Validate([]byte(config), hooks) {
+		got = append(got, p.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+// Package fixture records HTTP exchanges to a directory and replays them,
+// so that tools calling the CLI can be tested without the network.
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Exchange is one recorded request and its response, stored as a JSON file.
+type Exchange struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the part of a request used for matching. The API key is never
+// recorded.
+type Request struct {
+	Method string     `json:"method"`
+	Path   string     `json:"path"`
+	Query  url.Values `json:"query,omitempty"`
+	Body   Body       `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Body keeps JSON object and array bodies as JSON, so that fixtures stay
+// readable and easy to edit, and anything else as a string.
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if len(b) == 0 {
+		return []byte("null"), nil
+	}
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return compact(trimmed), nil
+	}
+	return json.Marshal(string(b))
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Body(s)
+		return nil
+	}
+	if string(data) == "null" {
+		*b = nil
+		return nil
+	}
+	*b = compact(data)
+	return nil
+}
+
+// compact removes insignificant whitespace from JSON, returning other data
+// unchanged.
+func compact(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
+
+// newRequest captures req without consuming its body.
+func newRequest(req *http.Request) (Request, error) {
+	query := req.URL.Query()
+	query.Del("apiKey")
+	if len(query) == 0 {
+		query = nil
+	}
+
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return Request{}, err
+		}
+		body, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return Request{}, err
+		}
+	}
+	return Request{Method: req.Method, Path: req.URL.Path, Query: query, Body: body}, nil
+}
+
+func (r Request) String() string {
+	s := r.Method + " " + r.Path
+	if len(r.Query) > 0 {
+		s += "?" + r.Query.Encode()
+	}
+	return s
+}
+
+// fileName returns the name of the n-th recorded exchange.
+func fileName(n int, req Request) string {
+	slug := strings.NewReplacer("/", "_", ".", "_").Replace(strings.Trim(req.Path, "/"))
+	return fmt.Sprintf("%04d-%s-%s.json", n, strings.ToLower(req.Method), slug)
+}
+
+// load reads every exchange in dir, in file name order.
+func load(dir string) ([]Exchange, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	exchanges := make([]Exchange, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var e Exchange
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %w", file, err)
+		}
+		exchanges = append(exchanges, e)
+	}
+	return exchanges, nil
+}
@@ -0,0 +1,124 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Recorder is an http.RoundTripper that saves every exchange passing
+// through it to Dir, one file per exchange.
+type Recorder struct {
+	// Base sends the requests. http.DefaultTransport is used when nil.
+	Base http.RoundTripper
+	Dir  string
+	// Scrub, when set, is applied to recorded bodies and header values,
+	// e.g. to remove API keys echoed by the server.
+	Scrub func(string) string
+
+	mu   sync.Mutex
+	next int
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := newRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	base := r.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	for name, values := range resp.Header {
+		for _, v := range values {
+			header.Add(name, r.scrub(v))
+		}
+	}
+	recorded.Body = Body(r.scrub(string(recorded.Body)))
+	e := Exchange{
+		Request:  recorded,
+		Response: Response{Status: resp.StatusCode, Header: header, Body: Body(r.scrub(string(body)))},
+	}
+	if err := r.save(e); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *Recorder) save(e Exchange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.next == 0 {
+		if err := os.MkdirAll(r.Dir, 0755); err != nil {
+			return err
+		}
+		// Continue after exchanges recorded by earlier runs.
+		r.next = lastNumber(r.Dir) + 1
+	}
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Never overwrite a fixture, e.g. one written meanwhile by another
+	// recording run: take the next number instead.
+	for {
+		f, err := os.OpenFile(filepath.Join(r.Dir, fileName(r.next, e.Request)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		r.next++
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+}
+
+// lastNumber returns the highest number of the exchanges recorded in dir,
+// or 0 when there are none.
+func lastNumber(dir string) int {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	last := 0
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(filepath.Base(file), "-")
+		if n, err := strconv.Atoi(prefix); err == nil && n > last {
+			last = n
+		}
+	}
+	return last
+}
+
+func (r *Recorder) scrub(s string) string {
+	if r.Scrub == nil {
+		return s
+	}
+	return r.Scrub(s)
+}
@@ -0,0 +1,164 @@
+package fixture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Matching modes of a Replayer.
+const (
+	// MatchStrict needs the method, path, query and body to be equal.
+	// The order of query parameters and of comma separated list values
+	// does not matter.
+	MatchStrict = "strict"
+	// MatchLenient needs the method and path to be equal and picks the
+	// recordings sharing the most query parameters.
+	MatchLenient = "lenient"
+)
+
+// ErrNoMatch is returned for requests without a matching recording.
+var ErrNoMatch = errors.New("no recorded response matches the request")
+
+// Replayer is an http.RoundTripper answering requests with the exchanges
+// recorded in a directory. It never uses the network.
+//
+// When several recordings match a request, they are replayed in the order
+// they were recorded, and the last one is repeated once all were used. A
+// recorded 429 or 5xx followed by another matching recording was retried
+// when recording, so it is skipped: replays send no retries and get the
+// answer the recorded run ended with.
+type Replayer struct {
+	dir       string
+	match     string
+	exchanges []Exchange
+
+	mu   sync.Mutex
+	used []bool
+}
+
+// NewReplayer loads the exchanges recorded in dir.
+func NewReplayer(dir, match string) (*Replayer, error) {
+	if match != MatchStrict && match != MatchLenient {
+		return nil, fmt.Errorf("unknown replay matching %q; use %s or %s", match, MatchStrict, MatchLenient)
+	}
+	exchanges, err := load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(exchanges) == 0 {
+		return nil, fmt.Errorf("no recordings found in %s", dir)
+	}
+	return &Replayer{dir: dir, match: match, exchanges: exchanges, used: make([]bool, len(exchanges))}, nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	wanted, err := newRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	e := r.find(wanted)
+	if e == nil {
+		return nil, fmt.Errorf("%w: %s (%s matching, %s)", ErrNoMatch, wanted, r.match, r.dir)
+	}
+
+	header := e.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Response.Status, http.StatusText(e.Response.Status)),
+		StatusCode:    e.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Response.Body)),
+		ContentLength: int64(len(e.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Replayer) find(wanted Request) *Exchange {
+	// Collect the indexes of the matching recordings, in recording order.
+	var matches []int
+	bestScore := -1
+	for i := range r.exchanges {
+		e := &r.exchanges[i]
+		if e.Request.Method != wanted.Method || e.Request.Path != wanted.Path {
+			continue
+		}
+		if r.match == MatchStrict {
+			if sameQuery(e.Request, wanted) && bytes.Equal(compact(e.Request.Body), compact(wanted.Body)) {
+				matches = append(matches, i)
+			}
+			continue
+		}
+		switch score := sharedParams(e.Request, wanted); {
+		case score > bestScore:
+			matches, bestScore = []int{i}, score
+		case score == bestScore:
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := matches[len(matches)-1]
+	for _, i := range matches {
+		if r.used[i] || i != last && retried(r.exchanges[i].Response.Status) {
+			continue
+		}
+		r.used[i] = true
+		return &r.exchanges[i]
+	}
+	return &r.exchanges[last]
+}
+
+// retried reports whether the client retries a response with status, as
+// client.Client does by default.
+func retried(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+func sameQuery(a, b Request) bool {
+	if len(a.Query) != len(b.Query) {
+		return false
+	}
+	for name := range a.Query {
+		if normalize(a.Query[name]) != normalize(b.Query[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sharedParams counts the query parameters with equal values in a and b.
+func sharedParams(a, b Request) int {
+	n := 0
+	for name, values := range a.Query {
+		if other, ok := b.Query[name]; ok && normalize(values) == normalize(other) {
+			n++
+		}
+	}
+	return n
+}
+
+// normalize joins values with their comma separated items sorted.
+func normalize(values []string) string {
+	var items []string
+	for _, v := range values {
+		items = append(items, strings.Split(v, ",")...)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,179 @@
+package fixture
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const recordings = "testdata/replay"
+
+// replay sends a request through r and returns the status and the fixture
+// number of the recording it was answered with, or the error.
+func replay(t *testing.T, r *Replayer, method, target, body string) (int, int, error) {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, "http://api.example.com"+target, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := r.RoundTrip(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	var payload struct {
+		Fixture int `json:"fixture"`
+	}
+	data, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(data, &payload)
+	return resp.StatusCode, payload.Fixture, nil
+}
+
+func newReplayer(t *testing.T, match string) *Replayer {
+	t.Helper()
+	r, err := NewReplayer(recordings, match)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestReplayMatching(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   string
+		method  string
+		target  string
+		body    string
+		fixture int // 0 for a bulk response, -1 for no match
+	}{
+		{"exact", MatchStrict, "GET", "/v3/ipgeo?ip=8.8.8.8&fields=location,asn", "", 1},
+		{"parameter order", MatchStrict, "GET", "/v3/ipgeo?fields=location,asn&ip=8.8.8.8", "", 1},
+		{"list order", MatchStrict, "GET", "/v3/ipgeo?ip=8.8.8.8&fields=asn,location", "", 1},
+		{"API key ignored", MatchStrict, "GET", "/v3/ipgeo?apiKey=k-123&ip=1.1.1.1&lang=de", "", 2},
+		{"missing parameter", MatchStrict, "GET", "/v3/ipgeo?ip=8.8.8.8", "", -1},
+		{"extra parameter", MatchStrict, "GET", "/v3/ipgeo?ip=1.1.1.1&lang=de&fields=asn", "", -1},
+		{"different value", MatchStrict, "GET", "/v3/ipgeo?ip=1.1.1.1&lang=fr", "", -1},
+		{"different path", MatchStrict, "GET", "/v3/abuse?ip=8.8.8.8", "", -1},
+		{"different method", MatchStrict, "POST", "/v3/ipgeo?ip=1.1.1.1&lang=de", "", -1},
+		{"body whitespace", MatchStrict, "POST", "/v3/ipgeo-bulk", `{ "ips": [ "8.8.8.8", "1.1.1.1" ] }`, 0},
+		{"different body", MatchStrict, "POST", "/v3/ipgeo-bulk", `{"ips":["8.8.4.4"]}`, -1},
+
+		{"lenient missing parameter", MatchLenient, "GET", "/v3/ipgeo?ip=8.8.8.8", "", 1},
+		{"lenient most shared", MatchLenient, "GET", "/v3/ipgeo?ip=1.1.1.1&lang=fr", "", 2},
+		{"lenient list order", MatchLenient, "GET", "/v3/ipgeo?lang=fr&fields=asn,location", "", 1},
+		{"lenient different body", MatchLenient, "POST", "/v3/ipgeo-bulk", `{"ips":["8.8.4.4"]}`, 0},
+		{"lenient different path", MatchLenient, "GET", "/v3/abuse?ip=8.8.8.8", "", -1},
+	}
+	for _, tt := range tests {
+		status, fixture, err := replay(t, newReplayer(t, tt.match), tt.method, tt.target, tt.body)
+		switch {
+		case tt.fixture < 0:
+			if !errors.Is(err, ErrNoMatch) {
+				t.Errorf("%s: got %v, want ErrNoMatch", tt.name, err)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case status != http.StatusOK || fixture != tt.fixture:
+			t.Errorf("%s: got fixture %d with status %d, want fixture %d", tt.name, fixture, status, tt.fixture)
+		}
+	}
+}
+
+func TestReplayOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   []int // fixtures answering successive requests
+		status int
+	}{
+		{"recorded order, then the last again", "/v3/timezone?tz=Europe/Berlin", []int{6, 7, 7, 7}, http.StatusOK},
+		{"retried failures skipped", "/v3/security?ip=9.9.9.9", []int{5, 5}, http.StatusOK},
+		{"a final failure is replayed", "/v3/asn?asn=AS15169", []int{9, 9}, http.StatusInternalServerError},
+	}
+	for _, match := range []string{MatchStrict, MatchLenient} {
+		for _, tt := range tests {
+			r := newReplayer(t, match)
+			for i, want := range tt.want {
+				status, fixture, err := replay(t, r, "GET", tt.target, "")
+				if err != nil {
+					t.Fatalf("%s, %s: %v", match, tt.name, err)
+				}
+				if fixture != want || status != tt.status {
+					t.Errorf("%s, %s: request %d got fixture %d with status %d, want fixture %d with %d", match, tt.name, i+1, fixture, status, want, tt.status)
+				}
+			}
+		}
+	}
+}
+
+func TestNewReplayer(t *testing.T) {
+	if _, err := NewReplayer(recordings, "fuzzy"); err == nil {
+		t.Error("unknown matching mode accepted")
+	}
+	if _, err := NewReplayer(t.TempDir(), MatchStrict); err == nil || !strings.Contains(err.Error(), "no recordings") {
+		t.Errorf("empty directory: got %v", err)
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "0001-get-v3_ipgeo.json"), []byte("{"), 0644)
+	if _, err := NewReplayer(dir, MatchStrict); err == nil || !strings.Contains(err.Error(), "invalid fixture") {
+		t.Errorf("broken fixture: got %v", err)
+	}
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	// Recordings from earlier runs, with a gap in their numbering.
+	dir := t.TempDir()
+	existing, err := os.ReadFile(filepath.Join(recordings, "0009-get-v3_asn.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"0001-get-v3_asn.json", "0003-get-v3_asn.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), existing, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := &Recorder{
+		Base:  newReplayer(t, MatchStrict),
+		Dir:   dir,
+		Scrub: func(s string) string { return strings.ReplaceAll(s, "k-123", "[REDACTED]") },
+	}
+	req, err := http.NewRequest("GET", "http://api.example.com/v3/timezone?tz=Europe/Berlin&apiKey=k-123", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := rec.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "0004-get-v3_timezone.json"))
+	if err != nil {
+		t.Fatalf("recording not numbered after the existing ones: %v", err)
+	}
+	if strings.Contains(string(data), "k-123") || strings.Contains(string(data), "apiKey") {
+		t.Errorf("recording contains the API key: %s", data)
+	}
+	if again, _ := os.ReadFile(filepath.Join(dir, "0003-get-v3_asn.json")); string(again) != string(existing) {
+		t.Error("an existing recording was overwritten")
+	}
+
+	r, err := NewReplayer(dir, MatchStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, fixture, err := replay(t, r, "GET", "/v3/timezone?tz=Europe/Berlin", ""); err != nil || fixture != 6 {
+		t.Errorf("replaying the new recording gave fixture %d, %v; want 6", fixture, err)
+	}
+}
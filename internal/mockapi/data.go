@@ -0,0 +1,398 @@
+package mockapi
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net"
+	"strings"
+	"time"
+)
+
+// place is a synthetic location. Lookups pick one from places by hashing
+// their input, so the same input always gets the same answer.
+type place struct {
+	ContinentCode, ContinentName       string
+	CountryCode2, CountryCode3         string
+	CountryName, CountryCapital        string
+	StateProv, StateCode, City, Zip    string
+	Latitude, Longitude                float64
+	IsEU                               bool
+	CallingCode, TLD                   string
+	Languages                          []string
+	CurrencyCode, CurrencyName, Symbol string
+	TimeZone                           string
+	Organization, ISP, Domain          string
+	ASN                                int
+}
+
+var places = []place{
+	{"NA", "North America", "US", "USA", "United States", "Washington, D.C.", "California", "US-CA", "Mountain View", "94043", 37.42240, -122.08421, false, "+1", ".us", []string{"en-US", "es-US"}, "USD", "US Dollar", "$", "America/Los_Angeles", "Example Networks LLC", "Example Networks", "example.net", 64500},
+	{"EU", "Europe", "DE", "DEU", "Germany", "Berlin", "Hesse", "DE-HE", "Frankfurt am Main", "60313", 50.11092, 8.68213, true, "+49", ".de", []string{"de"}, "EUR", "Euro", "€", "Europe/Berlin", "Beispiel Hosting GmbH", "Beispiel Hosting", "beispiel.de", 64501},
+	{"EU", "Europe", "GB", "GBR", "United Kingdom", "London", "England", "GB-ENG", "London", "EC1A", 51.50853, -0.12574, false, "+44", ".uk", []string{"en-GB", "cy-GB"}, "GBP", "British Pound", "£", "Europe/London", "Sample Telecom plc", "Sample Telecom", "sample.co.uk", 64502},
+	{"AS", "Asia", "JP", "JPN", "Japan", "Tokyo", "Tokyo", "JP-13", "Tokyo", "100-0001", 35.68950, 139.69171, false, "+81", ".jp", []string{"ja"}, "JPY", "Japanese Yen", "¥", "Asia/Tokyo", "Rei Networks K.K.", "Rei Networks", "rei.example.jp", 64503},
+	{"SA", "South America", "BR", "BRA", "Brazil", "Brasília", "São Paulo", "BR-SP", "São Paulo", "01000-000", -23.54750, -46.63611, false, "+55", ".br", []string{"pt-BR"}, "BRL", "Brazilian Real", "R$", "America/Sao_Paulo", "Exemplo Internet Ltda", "Exemplo Internet", "exemplo.com.br", 64504},
+	{"OC", "Oceania", "AU", "AUS", "Australia", "Canberra", "New South Wales", "AU-NSW", "Sydney", "2000", -33.86785, 151.20732, false, "+61", ".au", []string{"en-AU"}, "AUD", "Australian Dollar", "A$", "Australia/Sydney", "Demo Broadband Pty Ltd", "Demo Broadband", "demo.com.au", 64505},
+	{"AS", "Asia", "IN", "IND", "India", "New Delhi", "Karnataka", "IN-KA", "Bengaluru", "560001", 12.97194, 77.59369, false, "+91", ".in", []string{"en-IN", "hi", "kn"}, "INR", "Indian Rupee", "₹", "Asia/Kolkata", "Udaharan Networks Pvt Ltd", "Udaharan Networks", "udaharan.in", 64506},
+	{"AF", "Africa", "ZA", "ZAF", "South Africa", "Pretoria", "Gauteng", "ZA-GP", "Johannesburg", "2000", -26.20227, 28.04363, false, "+27", ".za", []string{"zu", "xh", "af", "en"}, "ZAR", "South African Rand", "R", "Africa/Johannesburg", "Voorbeeld Communications", "Voorbeeld Communications", "voorbeeld.co.za", 64507},
+}
+
+// hash returns a stable 64-bit hash of s.
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func placeFor(s string) place {
+	return places[hash(s)%uint64(len(places))]
+}
+
+// placeNear returns the place closest to a coordinate.
+func placeNear(lat, long float64) place {
+	best, bestDist := places[0], math.MaxFloat64
+	for _, p := range places {
+		d := (p.Latitude-lat)*(p.Latitude-lat) + (p.Longitude-long)*(p.Longitude-long)
+		if d < bestDist {
+			best, bestDist = p, d
+		}
+	}
+	return best
+}
+
+// placeForZone returns the place in the named time zone, if any.
+func placeForZone(name string) (place, bool) {
+	for _, p := range places {
+		if strings.EqualFold(p.TimeZone, name) {
+			return p, true
+		}
+	}
+	return place{}, false
+}
+
+func location(p place) map[string]interface{} {
+	return map[string]interface{}{
+		"continent_code":        p.ContinentCode,
+		"continent_name":        p.ContinentName,
+		"country_code2":         p.CountryCode2,
+		"country_code3":         p.CountryCode3,
+		"country_name":          p.CountryName,
+		"country_name_official": p.CountryName,
+		"country_capital":       p.CountryCapital,
+		"state_prov":            p.StateProv,
+		"state_code":            p.StateCode,
+		"district":              "",
+		"city":                  p.City,
+		"zipcode":               p.Zip,
+		"latitude":              fmt.Sprintf("%.5f", p.Latitude),
+		"longitude":             fmt.Sprintf("%.5f", p.Longitude),
+		"is_eu":                 p.IsEU,
+		"country_flag":          "https://ipgeolocation.io/static/flags/" + strings.ToLower(p.CountryCode2) + "_64.png",
+		"geoname_id":            fmt.Sprint(hash(p.City) % 10000000),
+		"country_emoji":         flagEmoji(p.CountryCode2),
+	}
+}
+
+func flagEmoji(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		b.WriteRune(0x1F1E6 + r - 'A')
+	}
+	return b.String()
+}
+
+func ipGeo(ip string, include []string, t time.Time) map[string]interface{} {
+	p := placeFor(ip)
+	result := map[string]interface{}{
+		"ip":       ip,
+		"location": location(p),
+		"country_metadata": map[string]interface{}{
+			"calling_code": p.CallingCode,
+			"tld":          p.TLD,
+			"languages":    p.Languages,
+		},
+		"currency": map[string]interface{}{
+			"code":   p.CurrencyCode,
+			"name":   p.CurrencyName,
+			"symbol": p.Symbol,
+		},
+		"asn": asnInfo(p),
+	}
+	for _, inc := range include {
+		switch inc {
+		case "security":
+			result["security"] = security(ip)
+		case "time_zone":
+			result["time_zone"] = timeZone(p.TimeZone, t)
+		case "abuse":
+			result["abuse"] = abuse(ip)
+		case "*":
+			result["security"] = security(ip)
+			result["time_zone"] = timeZone(p.TimeZone, t)
+			result["abuse"] = abuse(ip)
+		}
+	}
+	return result
+}
+
+func security(ip string) map[string]interface{} {
+	h := hash("security:" + ip)
+	score := int(h % 100)
+	isProxy := score > 80
+	isVPN := score > 70 && !isProxy
+	isTor := score > 95
+	return map[string]interface{}{
+		"threat_score":           score,
+		"is_tor":                 isTor,
+		"is_proxy":               isProxy,
+		"proxy_provider_names":   providerNames(isProxy, "ExampleProxy"),
+		"proxy_confidence_score": confidence(isProxy, h),
+		"proxy_last_seen":        lastSeen(isProxy),
+		"is_residential_proxy":   isProxy && h%2 == 0,
+		"is_vpn":                 isVPN,
+		"vpn_provider_names":     providerNames(isVPN, "ExampleVPN"),
+		"vpn_confidence_score":   confidence(isVPN, h),
+		"vpn_last_seen":          lastSeen(isVPN),
+		"is_relay":               false,
+		"relay_provider_name":    "",
+		"is_anonymous":           isProxy || isVPN || isTor,
+		"is_known_attacker":      score > 90,
+		"is_bot":                 score > 85,
+		"is_spam":                score > 88,
+		"is_cloud_provider":      h%5 == 0,
+		"cloud_provider_name":    map[bool]string{true: "Example Cloud", false: ""}[h%5 == 0],
+	}
+}
+
+func providerNames(on bool, name string) []string {
+	if !on {
+		return []string{}
+	}
+	return []string{name}
+}
+
+func confidence(on bool, h uint64) int {
+	if !on {
+		return 0
+	}
+	return 70 + int(h%30)
+}
+
+func lastSeen(on bool) string {
+	if !on {
+		return ""
+	}
+	return "2025-01-01"
+}
+
+func asnInfo(p place) map[string]interface{} {
+	return map[string]interface{}{
+		"as_number":    fmt.Sprintf("AS%d", p.ASN),
+		"organization": p.Organization,
+		"country":      p.CountryCode2,
+	}
+}
+
+func asnDetails(p place, include []string) map[string]interface{} {
+	details := map[string]interface{}{
+		"as_number":          fmt.Sprintf("AS%d", p.ASN),
+		"organization":       p.Organization,
+		"country":            p.CountryCode2,
+		"asn_name":           strings.ToUpper(strings.Fields(p.ISP)[0]) + "-AS",
+		"type":               "ISP",
+		"domain":             p.Domain,
+		"date_allocated":     "2005-06-15",
+		"allocation_status":  "assigned",
+		"num_of_ipv4_routes": fmt.Sprint(10 + hash(p.ISP)%90),
+		"num_of_ipv6_routes": fmt.Sprint(1 + hash(p.Domain)%9),
+		"rir":                "ARIN",
+	}
+	for _, inc := range include {
+		switch inc {
+		case "peers", "downstreams", "upstreams":
+			var list []map[string]interface{}
+			for i := 1; i <= 2; i++ {
+				other := places[(p.ASN+i)%len(places)]
+				list = append(list, map[string]interface{}{
+					"as_number":   fmt.Sprintf("AS%d", other.ASN),
+					"description": other.Organization,
+					"country":     other.CountryCode2,
+				})
+			}
+			details[inc] = list
+		case "routes":
+			details["routes"] = []string{fmt.Sprintf("198.51.%d.0/24", p.ASN%256), fmt.Sprintf("2001:db8:%x::/48", p.ASN)}
+		case "whois_response":
+			details["whois_response"] = fmt.Sprintf("ASNumber: %d\nASName: %s\nOrgName: %s\n", p.ASN, details["asn_name"], p.Organization)
+		}
+	}
+	return details
+}
+
+func abuse(ip string) map[string]interface{} {
+	p := placeFor(ip)
+	return map[string]interface{}{
+		"route":         routeFor(ip),
+		"country":       p.CountryCode2,
+		"name":          p.ISP + " Abuse",
+		"organization":  p.Organization,
+		"kind":          "group",
+		"address":       p.City + ", " + p.CountryName,
+		"emails":        []string{"abuse@" + p.Domain},
+		"phone_numbers": []string{p.CallingCode + " 555 0100"},
+	}
+}
+
+func routeFor(ip string) string {
+	parsed := net.ParseIP(ip)
+	if v4 := parsed.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.0/24", v4[0], v4[1], v4[2])
+	}
+	if parsed != nil {
+		mask := net.CIDRMask(48, 128)
+		return (&net.IPNet{IP: parsed.Mask(mask), Mask: mask}).String()
+	}
+	return ""
+}
+
+func timeZone(name string, t time.Time) map[string]interface{} {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = time.UTC
+		name = "UTC"
+	}
+	local := t.In(loc)
+	_, offset := local.Zone()
+	_, janOffset := time.Date(local.Year(), 1, 1, 0, 0, 0, 0, loc).Zone()
+	_, julOffset := time.Date(local.Year(), 7, 1, 0, 0, 0, 0, loc).Zone()
+	standard := janOffset
+	if julOffset < standard {
+		standard = julOffset
+	}
+	return map[string]interface{}{
+		"name":            name,
+		"offset":          float64(standard) / 3600,
+		"offset_with_dst": float64(offset) / 3600,
+		"date":            local.Format("2006-01-02"),
+		"date_time":       local.Format("2006-01-02 15:04:05"),
+		"date_time_txt":   local.Format("Monday, January 02, 2006 15:04:05"),
+		"date_time_wti":   local.Format("Mon, 02 Jan 2006 15:04:05 -0700"),
+		"date_time_ymd":   local.Format("2006-01-02T15:04:05-0700"),
+		"date_time_unix":  float64(local.Unix()),
+		"time_24":         local.Format("15:04:05"),
+		"time_12":         local.Format("03:04:05 PM"),
+		"week":            weekOf(local),
+		"month":           int(local.Month()),
+		"year":            local.Year(),
+		"year_abbr":       local.Format("06"),
+		"is_dst":          offset != standard,
+		"dst_savings":     float64(offset-standard) / 3600,
+	}
+}
+
+func weekOf(t time.Time) int {
+	_, week := t.ISOWeek()
+	return week
+}
+
+// astronomyDay returns synthetic sun and moon data for a place and day.
+// The values vary smoothly with the latitude and the day of the year.
+func astronomyDay(p place, day time.Time, loc *time.Location) map[string]interface{} {
+	yearDay := float64(day.YearDay())
+	// Day length swings by up to ±4h with the season, mirrored south of
+	// the equator.
+	swing := 4 * math.Sin(2*math.Pi*(yearDay-80)/365) * p.Latitude / 60
+	dayLength := time.Duration((12 + swing) * float64(time.Hour))
+	noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, loc)
+	sunrise := noon.Add(-dayLength / 2)
+	sunset := noon.Add(dayLength / 2)
+	phase := math.Mod(yearDay/29.53, 1)
+	moonrise := noon.Add(time.Duration(phase*24*float64(time.Hour)) - 6*time.Hour)
+
+	return map[string]interface{}{
+		"date":                         day.Format("2006-01-02"),
+		"sunrise":                      sunrise.Format("15:04"),
+		"sunset":                       sunset.Format("15:04"),
+		"sun_status":                   "-",
+		"solar_noon":                   noon.Format("15:04"),
+		"day_length":                   fmt.Sprintf("%02d:%02d", int(dayLength.Hours()), int(dayLength.Minutes())%60),
+		"moonrise":                     moonrise.Format("15:04"),
+		"moonset":                      moonrise.Add(12 * time.Hour).Format("15:04"),
+		"moon_status":                  "-",
+		"moon_phase":                   moonPhase(phase),
+		"moon_illumination_percentage": fmt.Sprintf("%.2f", 50*(1-math.Cos(2*math.Pi*phase))),
+		"moon_angle":                   360 * phase,
+	}
+}
+
+func moonPhase(phase float64) string {
+	names := []string{"NEW_MOON", "WAXING_CRESCENT", "FIRST_QUARTER", "WAXING_GIBBOUS", "FULL_MOON", "WANING_GIBBOUS", "LAST_QUARTER", "WANING_CRESCENT"}
+	return names[int(phase*8+0.5)%8]
+}
+
+// userAgent parses a user agent string with a few well-known patterns.
+func userAgent(ua string) map[string]interface{} {
+	name, kind, version := "Unknown", "Unknown", "??"
+	for _, b := range []struct{ token, name, kind string }{
+		{"Edg/", "Edge", "Browser"},
+		{"OPR/", "Opera", "Browser"},
+		{"Firefox/", "Firefox", "Browser"},
+		{"Chrome/", "Chrome", "Browser"},
+		{"Version/", "Safari", "Browser"},
+		{"curl/", "curl", "Cloud Application"},
+		{"Wget/", "Wget", "Cloud Application"},
+		{"Googlebot/", "Googlebot", "Robot"},
+		{"python-requests/", "Python Requests", "Cloud Application"},
+	} {
+		if i := strings.Index(ua, b.token); i >= 0 {
+			name, kind = b.name, b.kind
+			if rest := strings.FieldsFunc(ua[i+len(b.token):], func(r rune) bool { return r == ' ' || r == ';' || r == ')' }); len(rest) > 0 {
+				version = rest[0]
+			}
+			break
+		}
+	}
+
+	osName, osVersion, deviceType, deviceName := "Unknown", "??", "Unknown", "Unknown"
+	switch {
+	case strings.Contains(ua, "Android"):
+		osName, deviceType, deviceName = "Android", "Phone", "Android Phone"
+	case strings.Contains(ua, "iPhone"):
+		osName, deviceType, deviceName = "iOS", "Phone", "Apple iPhone"
+	case strings.Contains(ua, "Windows NT 10.0"):
+		osName, osVersion, deviceType, deviceName = "Windows NT", "10.0", "Desktop", "Desktop"
+	case strings.Contains(ua, "Mac OS X"):
+		osName, deviceType, deviceName = "Mac OS", "Desktop", "Apple Macintosh"
+	case strings.Contains(ua, "Linux"):
+		osName, deviceType, deviceName = "Linux", "Desktop", "Linux Desktop"
+	case kind != "Unknown":
+		deviceType, deviceName = "Cloud", "Cloud"
+	}
+
+	return map[string]interface{}{
+		"user_agent_string": ua,
+		"name":              name,
+		"type":              kind,
+		"version":           version,
+		"version_major":     strings.Split(version, ".")[0],
+		"device": map[string]interface{}{
+			"name":  deviceName,
+			"type":  deviceType,
+			"brand": "Unknown",
+			"cpu":   "Unknown",
+		},
+		"engine": map[string]interface{}{
+			"name":          name,
+			"type":          kind,
+			"version":       version,
+			"version_major": strings.Split(version, ".")[0],
+		},
+		"operating_system": map[string]interface{}{
+			"name":          osName,
+			"type":          deviceType,
+			"version":       osVersion,
+			"version_major": strings.Split(osVersion, ".")[0],
+			"build":         "??",
+		},
+	}
+}
@@ -0,0 +1,605 @@
+// Package mockapi serves a local imitation of the IPGeolocation.io v3 API.
+//
+// Every answer is synthetic and derived from the request alone, so the same
+// request always gets the same response body (apart from the current time in
+// time zone and astronomy answers, which can be pinned with Options.Now).
+// Failures and latency can be injected to exercise retries and error
+// handling without touching the network.
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// PathPrefix is the path the API is served under, as in the real API's
+// base URL.
+const PathPrefix = "/v3"
+
+// maxBulk is the largest number of items accepted by a bulk endpoint.
+const maxBulk = 50000
+
+// Options configure a Server.
+type Options struct {
+	// APIKey, when set, is the only apiKey accepted; other keys get a 401.
+	// Requests without any apiKey always get a 401.
+	APIKey string
+	// FailStatus, when set, is the status returned by injected failures,
+	// e.g. 429 or 503.
+	FailStatus int
+	// FailEvery makes every FailEvery-th request fail with FailStatus;
+	// 0 or 1 fails them all.
+	FailEvery int
+	// Latency delays every response.
+	Latency time.Duration
+	// Now is the clock used for time zone and astronomy answers. It
+	// defaults to time.Now.
+	Now func() time.Time
+	// Logf, when set, receives one line per request.
+	Logf func(format string, args ...interface{})
+}
+
+// Server is an http.Handler answering the IPGeolocation.io v3 routes.
+type Server struct {
+	// requests is updated atomically, so it comes first to keep it 64-bit
+	// aligned on 32-bit platforms.
+	requests uint64
+	opts     Options
+	mux      *http.ServeMux
+}
+
+// New returns a Server configured by opts.
+func New(opts Options) *Server {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+	routes := map[string]func(*http.Request) (interface{}, error){
+		"ipgeo":                s.ipGeo,
+		"ipgeo-bulk":           s.ipGeoBulk,
+		"security":             s.security,
+		"security-bulk":        s.securityBulk,
+		"asn":                  s.asn,
+		"abuse":                s.abuse,
+		"timezone":             s.timezone,
+		"timezone/convert":     s.convertTime,
+		"astronomy":            s.astronomy,
+		"astronomy/timeSeries": s.astronomyTimeSeries,
+		"user-agent":           s.userAgent,
+		"user-agent-bulk":      s.userAgentBulk,
+	}
+	for path, handler := range routes {
+		s.mux.Handle(PathPrefix+"/"+path, handle(handler))
+	}
+	return s
+}
+
+// ServeHTTP applies the configured latency, authentication and failure
+// injection, then routes the request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		if s.opts.Logf == nil {
+			return
+		}
+		status := strconv.Itoa(rec.status)
+		if r.Context().Err() != nil {
+			status = "canceled"
+		}
+		s.opts.Logf("%s %s %s %s", r.Method, logURL(r.URL), status, time.Since(start).Round(time.Millisecond))
+	}()
+
+	if s.opts.Latency > 0 {
+		select {
+		case <-time.After(s.opts.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	key := r.URL.Query().Get("apiKey")
+	if key == "" || (s.opts.APIKey != "" && key != s.opts.APIKey) {
+		writeError(rec, http.StatusUnauthorized, "Provided API key is not valid. Contact technical support for assistance at support@ipgeolocation.io")
+		return
+	}
+
+	n := atomic.AddUint64(&s.requests, 1)
+	if s.opts.FailStatus != 0 && (s.opts.FailEvery <= 1 || n%uint64(s.opts.FailEvery) == 0) {
+		if s.opts.FailStatus == http.StatusTooManyRequests {
+			rec.Header().Set("Retry-After", "1")
+		}
+		writeError(rec, s.opts.FailStatus, injectedMessage(s.opts.FailStatus))
+		return
+	}
+
+	s.mux.ServeHTTP(rec, r)
+}
+
+func injectedMessage(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return "Provided API key is not valid. Contact technical support for assistance at support@ipgeolocation.io"
+	case http.StatusTooManyRequests:
+		return "You have exceeded the rate limit of your subscription. Please try again later."
+	}
+	return fmt.Sprintf("%s (injected by mock-server)", http.StatusText(status))
+}
+
+// statusRecorder remembers the status written, for the request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logURL returns u without its apiKey.
+func logURL(u *url.URL) string {
+	query := u.Query()
+	query.Del("apiKey")
+	if len(query) == 0 {
+		return u.Path
+	}
+	return u.Path + "?" + query.Encode()
+}
+
+// badRequest is an error answered with a 400.
+type badRequest string
+
+func (e badRequest) Error() string {
+	return string(e)
+}
+
+// handle adapts an endpoint function to an http.Handler. Endpoints return
+// the response body, or a badRequest for invalid input.
+func handle(endpoint func(*http.Request) (interface{}, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := endpoint(r)
+		if err != nil {
+			if _, ok := err.(badRequest); ok {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// requireMethod returns a badRequest unless r uses method.
+func requireMethod(r *http.Request, method string) error {
+	if r.Method != method {
+		return badRequest(fmt.Sprintf("%s is not supported on %s; use %s.", r.Method, r.URL.Path, method))
+	}
+	return nil
+}
+
+// list splits a comma-separated query parameter.
+func list(query url.Values, key string) []string {
+	var items []string
+	for _, item := range strings.Split(query.Get(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// decodeBody reads a JSON object of string arrays or strings from r.
+func decodeBody(r *http.Request, v interface{}) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return badRequest("Invalid JSON request body.")
+	}
+	return nil
+}
+
+// resolveIP returns the address to look up: the ip parameter, or the
+// caller's own address. Domain names get a synthetic documentation address.
+func resolveIP(r *http.Request) (ip, domain string, err error) {
+	ip = r.URL.Query().Get("ip")
+	if ip == "" {
+		host, _, splitErr := net.SplitHostPort(r.RemoteAddr)
+		if splitErr != nil {
+			host = r.RemoteAddr
+		}
+		return host, "", nil
+	}
+	return checkIP(ip)
+}
+
+func checkIP(ip string) (string, string, error) {
+	if net.ParseIP(ip) != nil {
+		return ip, "", nil
+	}
+	if strings.Contains(ip, ".") && strings.ContainsAny(strings.ToLower(ip), "abcdefghijklmnopqrstuvwxyz") && !strings.ContainsAny(ip, " /:") {
+		return fmt.Sprintf("203.0.113.%d", hash(ip)%254+1), ip, nil
+	}
+	return "", "", badRequest(fmt.Sprintf("'%s' is not a valid IP address.", ip))
+}
+
+func (s *Server) ipGeo(r *http.Request) (interface{}, error) {
+	ip, domain, err := resolveIP(r)
+	if err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	result := ipGeo(ip, list(query, "include"), s.opts.Now())
+	if domain != "" {
+		result["domain"] = domain
+	}
+	return filter(result, list(query, "fields"), list(query, "excludes")), nil
+}
+
+func (s *Server) ipGeoBulk(r *http.Request) (interface{}, error) {
+	ips, err := bulkIPs(r)
+	if err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	results := make([]interface{}, 0, len(ips))
+	for _, raw := range ips {
+		ip, domain, err := checkIP(raw)
+		if err != nil {
+			results = append(results, map[string]interface{}{"message": err.Error()})
+			continue
+		}
+		result := ipGeo(ip, list(query, "include"), s.opts.Now())
+		if domain != "" {
+			result["domain"] = domain
+		}
+		results = append(results, filter(result, list(query, "fields"), list(query, "excludes")))
+	}
+	return results, nil
+}
+
+func bulkIPs(r *http.Request) ([]string, error) {
+	if err := requireMethod(r, http.MethodPost); err != nil {
+		return nil, err
+	}
+	var body struct {
+		IPs []string `json:"ips"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	if len(body.IPs) == 0 || len(body.IPs) > maxBulk {
+		return nil, badRequest(fmt.Sprintf("'ips' must contain 1 to %d IP addresses.", maxBulk))
+	}
+	return body.IPs, nil
+}
+
+func (s *Server) security(r *http.Request) (interface{}, error) {
+	ip, _, err := resolveIP(r)
+	if err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	result := map[string]interface{}{"ip": ip, "security": security(ip)}
+	return filter(result, list(query, "fields"), list(query, "excludes")), nil
+}
+
+func (s *Server) securityBulk(r *http.Request) (interface{}, error) {
+	ips, err := bulkIPs(r)
+	if err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	results := make([]interface{}, 0, len(ips))
+	for _, raw := range ips {
+		if net.ParseIP(raw) == nil {
+			results = append(results, map[string]interface{}{"message": fmt.Sprintf("'%s' is not a valid IP address.", raw)})
+			continue
+		}
+		result := map[string]interface{}{"ip": raw, "security": security(raw)}
+		results = append(results, filter(result, list(query, "fields"), list(query, "excludes")))
+	}
+	return results, nil
+}
+
+func (s *Server) asn(r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	result := map[string]interface{}{}
+	var p place
+	if asn := strings.TrimPrefix(strings.ToUpper(query.Get("asn")), "AS"); asn != "" {
+		number, err := strconv.Atoi(asn)
+		if err != nil || number <= 0 {
+			return nil, badRequest(fmt.Sprintf("'%s' is not a valid ASN.", query.Get("asn")))
+		}
+		p = placeFor("AS" + asn)
+		p.ASN = number
+	} else {
+		ip, _, err := resolveIP(r)
+		if err != nil {
+			return nil, err
+		}
+		result["ip"] = ip
+		p = placeFor(ip)
+	}
+	result["asn"] = asnDetails(p, list(query, "include"))
+	return filter(result, list(query, "fields"), list(query, "excludes")), nil
+}
+
+func (s *Server) abuse(r *http.Request) (interface{}, error) {
+	ip, _, err := resolveIP(r)
+	if err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	result := map[string]interface{}{"ip": ip, "abuse": abuse(ip)}
+	return filter(result, list(query, "fields"), list(query, "excludes")), nil
+}
+
+// locate resolves the place named by a request's time zone, location,
+// coordinate, code or IP parameters. suffix is appended to every parameter
+// name, for the _from and _to variants of the time conversion API.
+func locate(r *http.Request, suffix string) (place, string, error) {
+	query := r.URL.Query()
+	get := func(key string) string { return query.Get(key + suffix) }
+
+	if tz := firstOf(get("tz"), get("time_zone")); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return place{}, "", badRequest(fmt.Sprintf("'%s' is not a valid time zone.", tz))
+		}
+		p, ok := placeForZone(tz)
+		if !ok {
+			p = placeFor(tz)
+		}
+		return p, tz, nil
+	}
+	if lat, long := get("lat"), get("long"); lat != "" || long != "" {
+		latitude, err1 := strconv.ParseFloat(lat, 64)
+		longitude, err2 := strconv.ParseFloat(long, 64)
+		if err1 != nil || err2 != nil || math.Abs(latitude) > 90 || math.Abs(longitude) > 180 {
+			return place{}, "", badRequest("Provide a valid latitude and longitude.")
+		}
+		p := placeNear(latitude, longitude)
+		return p, p.TimeZone, nil
+	}
+	for _, key := range []string{"location", "iata_code", "iata", "icao_code", "icao", "lo_code", "locode"} {
+		if value := get(key); value != "" {
+			p := placeFor(strings.ToLower(value))
+			return p, p.TimeZone, nil
+		}
+	}
+	if suffix != "" {
+		return place{}, "", badRequest(fmt.Sprintf("Provide a time zone, location, coordinates or code for '%s'.", strings.TrimPrefix(suffix, "_")))
+	}
+	ip, _, err := resolveIP(r)
+	if err != nil {
+		return place{}, "", err
+	}
+	p := placeFor(ip)
+	return p, p.TimeZone, nil
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (s *Server) timezone(r *http.Request) (interface{}, error) {
+	p, tz, err := locate(r, "")
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{"time_zone": timeZone(tz, s.opts.Now())}
+	if ip := r.URL.Query().Get("ip"); ip != "" {
+		result["ip"] = ip
+		result["location"] = location(p)
+	}
+	return result, nil
+}
+
+func (s *Server) convertTime(r *http.Request) (interface{}, error) {
+	_, from, err := locate(r, "_from")
+	if err != nil {
+		return nil, err
+	}
+	_, to, err := locate(r, "_to")
+	if err != nil {
+		return nil, err
+	}
+	fromLoc, _ := time.LoadLocation(from)
+	toLoc, _ := time.LoadLocation(to)
+
+	original := s.opts.Now().In(fromLoc)
+	if value := r.URL.Query().Get("time"); value != "" {
+		parsed, err := parseTime(value, fromLoc)
+		if err != nil {
+			return nil, err
+		}
+		original = parsed
+	}
+	converted := original.In(toLoc)
+	_, fromOffset := original.Zone()
+	_, toOffset := converted.Zone()
+	diff := time.Duration(toOffset-fromOffset) * time.Second
+	return map[string]interface{}{
+		"original_time":  original.Format("2006-01-02 15:04:05"),
+		"converted_time": converted.Format("2006-01-02 15:04:05"),
+		"diff_hour":      diff.Hours(),
+		"diff_min":       int(diff.Minutes()),
+	}, nil
+}
+
+func parseTime(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, badRequest(fmt.Sprintf("'%s' is not a valid time; use yyyy-MM-dd HH:mm or yyyy-MM-dd HH:mm:ss.", value))
+}
+
+func astronomyLocation(p place, tz string) map[string]interface{} {
+	loc := location(p)
+	loc["time_zone"] = tz
+	return loc
+}
+
+func (s *Server) astronomy(r *http.Request) (interface{}, error) {
+	p, tz, err := locate(r, "")
+	if err != nil {
+		return nil, err
+	}
+	loc, _ := time.LoadLocation(tz)
+	now := s.opts.Now().In(loc)
+	day := now
+	if value := r.URL.Query().Get("date"); value != "" {
+		if day, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			return nil, badRequest(fmt.Sprintf("'%s' is not a valid date; use yyyy-MM-dd.", value))
+		}
+	}
+	astronomy := astronomyDay(p, day, loc)
+	astronomy["current_time"] = now.Format("15:04:05.000")
+	return map[string]interface{}{
+		"location":  astronomyLocation(p, tz),
+		"astronomy": astronomy,
+	}, nil
+}
+
+func (s *Server) astronomyTimeSeries(r *http.Request) (interface{}, error) {
+	p, tz, err := locate(r, "")
+	if err != nil {
+		return nil, err
+	}
+	loc, _ := time.LoadLocation(tz)
+	query := r.URL.Query()
+	start, err1 := time.ParseInLocation("2006-01-02", query.Get("dateStart"), loc)
+	end, err2 := time.ParseInLocation("2006-01-02", query.Get("dateEnd"), loc)
+	if err1 != nil || err2 != nil {
+		return nil, badRequest("'dateStart' and 'dateEnd' are required, in the format yyyy-MM-dd.")
+	}
+	if end.Before(start) || end.Sub(start) > 90*24*time.Hour {
+		return nil, badRequest("'dateEnd' must be on or after 'dateStart', and at most 90 days later.")
+	}
+	var days []interface{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, astronomyDay(p, day, loc))
+	}
+	return map[string]interface{}{
+		"location":  astronomyLocation(p, tz),
+		"astronomy": days,
+	}, nil
+}
+
+func (s *Server) userAgent(r *http.Request) (interface{}, error) {
+	if err := requireMethod(r, http.MethodPost); err != nil {
+		return nil, err
+	}
+	var body struct {
+		UAString string `json:"uaString"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	if body.UAString == "" {
+		body.UAString = r.UserAgent()
+	}
+	return userAgent(body.UAString), nil
+}
+
+func (s *Server) userAgentBulk(r *http.Request) (interface{}, error) {
+	if err := requireMethod(r, http.MethodPost); err != nil {
+		return nil, err
+	}
+	var body struct {
+		UAStrings []string `json:"uaStrings"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	if len(body.UAStrings) == 0 || len(body.UAStrings) > maxBulk {
+		return nil, badRequest(fmt.Sprintf("'uaStrings' must contain 1 to %d user agent strings.", maxBulk))
+	}
+	results := make([]interface{}, 0, len(body.UAStrings))
+	for _, ua := range body.UAStrings {
+		results = append(results, userAgent(ua))
+	}
+	return results, nil
+}
+
+// filter applies the fields and excludes parameters to a response. Both
+// take dotted paths such as location.city; "ip" is always kept.
+func filter(result map[string]interface{}, fields, excludes []string) map[string]interface{} {
+	if len(fields) > 0 {
+		kept := map[string]interface{}{}
+		if ip, ok := result["ip"]; ok {
+			kept["ip"] = ip
+		}
+		for _, field := range fields {
+			if value, ok := lookup(result, strings.Split(field, ".")); ok {
+				store(kept, strings.Split(field, "."), value)
+			}
+		}
+		result = kept
+	}
+	for _, exclude := range excludes {
+		remove(result, strings.Split(exclude, "."))
+	}
+	return result
+}
+
+func lookup(m map[string]interface{}, path []string) (interface{}, bool) {
+	value, ok := m[path[0]]
+	if !ok || len(path) == 1 {
+		return value, ok
+	}
+	child, isMap := value.(map[string]interface{})
+	if !isMap {
+		return nil, false
+	}
+	return lookup(child, path[1:])
+}
+
+func store(m map[string]interface{}, path []string, value interface{}) {
+	if len(path) == 1 {
+		m[path[0]] = value
+		return
+	}
+	child, ok := m[path[0]].(map[string]interface{})
+	if !ok {
+		child = map[string]interface{}{}
+		m[path[0]] = child
+	}
+	store(child, path[1:], value)
+}
+
+func remove(m map[string]interface{}, path []string) {
+	if len(path) == 1 {
+		delete(m, path[0])
+		return
+	}
+	if child, ok := m[path[0]].(map[string]interface{}); ok {
+		remove(child, path[1:])
+	}
+}
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/IPGeolocation/cli/v2/internal/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	Register("pretty", "Indented JSON (the default)", RendererFunc(renderPretty))
+	Register("raw", "The response body exactly as returned by the API", RendererFunc(renderRaw))
+	Register("yaml", "YAML", RendererFunc(renderYAML))
+	Register("table", "Readable field names: a grid with one row per result for bulk responses or --columns, else an indented list", RendererFunc(renderTable))
+	Register("csv", "Comma-separated values: one row per result, nested fields as dotted columns", renderDelimited(','))
+	Register("tsv", "Tab-separated values, like csv", renderDelimited('\t'))
+	Register("xml", "XML, with one root element per endpoint and arrays as repeated elements", RendererFunc(renderXML))
+}
+
+func renderPretty(w io.Writer, doc *Document) error {
+	pretty, err := json.MarshalIndent(doc.Data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to convert to JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(pretty))
+	return err
+}
+
+func renderRaw(w io.Writer, doc *Document) error {
+	_, err := fmt.Fprintln(w, string(doc.Raw))
+	return err
+}
+
+func renderYAML(w io.Writer, doc *Document) error {
+	yamlData, err := yaml.Marshal(doc.Data)
+	if err != nil {
+		return fmt.Errorf("failed to convert to YAML: %w", err)
+	}
+	_, err = w.Write(yamlData)
+	return err
+}
+
+// renderTable draws list-shaped results, or the selected columns, as a grid
+// and anything else as an indented list of fields.
+func renderTable(w io.Writer, doc *Document) error {
+	if _, list := doc.Data.([]interface{}); list || len(doc.Columns) > 0 {
+		return renderGrid(w, doc)
+	}
+	utils.FprintAsTable(w, doc.Data, 0)
+	return nil
+}
+
+func renderXML(w io.Writer, doc *Document) error {
+	_, err := io.WriteString(w, utils.ConvertToXML(doc.Name, doc.Data))
+	return err
+}
@@ -0,0 +1,227 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/IPGeolocation/cli/v2/internal/utils"
+
+	"golang.org/x/text/width"
+)
+
+// Border styles of the table grid.
+const (
+	BorderBox   = "box"
+	BorderASCII = "ascii"
+	BorderNone  = "none"
+)
+
+// Borders lists the border styles, the default first.
+var Borders = []string{BorderBox, BorderASCII, BorderNone}
+
+// border holds the characters drawing a grid.
+type border struct {
+	// horizontal, vertical and the junctions: top, middle (below the
+	// header) and bottom rows, each as left, inner and right.
+	h, v              string
+	top, middle, foot [3]string
+	// ellipsis marks a truncated cell.
+	ellipsis string
+}
+
+var borders = map[string]border{
+	BorderBox: {
+		h: "─", v: "│",
+		top:      [3]string{"┌", "┬", "┐"},
+		middle:   [3]string{"├", "┼", "┤"},
+		foot:     [3]string{"└", "┴", "┘"},
+		ellipsis: "…",
+	},
+	BorderASCII: {
+		h: "-", v: "|",
+		top:      [3]string{"+", "+", "+"},
+		middle:   [3]string{"+", "+", "+"},
+		foot:     [3]string{"+", "+", "+"},
+		ellipsis: "~",
+	},
+}
+
+// ValidateBorder checks a border style name; empty selects BorderBox.
+func ValidateBorder(name string) error {
+	for _, b := range Borders {
+		if name == b || name == "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown border style %q; available styles: %s", name, strings.Join(Borders, ", "))
+}
+
+// renderGrid writes a table as a grid with one row per result, shrinking
+// the widest columns to fit doc.Width.
+func renderGrid(w io.Writer, doc *Document) error {
+	t := flatten(doc)
+	if len(t.columns) == 0 {
+		return nil
+	}
+
+	headers := make([]string, len(t.columns))
+	widths := make([]int, len(t.columns))
+	for i, column := range t.columns {
+		headers[i] = gridHeader(column)
+		widths[i] = textWidth(headers[i])
+		for _, row := range t.rows {
+			row[column] = strings.Join(strings.Fields(row[column]), " ")
+			if n := textWidth(row[column]); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	style := doc.Border
+	if style == "" {
+		style = BorderBox
+	}
+	b, framed := borders[style]
+	if !framed {
+		b = border{ellipsis: "…"}
+	}
+	if doc.Width > 0 {
+		fitWidths(widths, doc.Width-gridOverhead(len(widths), framed))
+	}
+
+	var out strings.Builder
+	line := func(junctions [3]string) {
+		if !framed {
+			return
+		}
+		out.WriteString(junctions[0])
+		for i, width := range widths {
+			if i > 0 {
+				out.WriteString(junctions[1])
+			}
+			out.WriteString(strings.Repeat(b.h, width+2))
+		}
+		out.WriteString(junctions[2] + "\n")
+	}
+	row := func(cells []string) {
+		for i, width := range widths {
+			text := truncate(cells[i], width, b.ellipsis)
+			pad := strings.Repeat(" ", width-textWidth(text))
+			switch {
+			case framed:
+				out.WriteString(b.v + " " + text + pad + " ")
+			case i < len(widths)-1:
+				out.WriteString(text + pad + "  ")
+			default:
+				out.WriteString(text)
+			}
+		}
+		if framed {
+			out.WriteString(b.v)
+		}
+		out.WriteString("\n")
+	}
+
+	line(b.top)
+	row(headers)
+	line(b.middle)
+	cells := make([]string, len(t.columns))
+	for _, r := range t.rows {
+		for i, column := range t.columns {
+			cells[i] = r[column]
+		}
+		row(cells)
+	}
+	line(b.foot)
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// gridHeader names a column for the grid, e.g. "Location City" for
+// "location.city".
+func gridHeader(column string) string {
+	parts := strings.Split(column, ".")
+	for i, p := range parts {
+		parts[i] = utils.ToTitle(p)
+	}
+	return strings.Join(parts, " ")
+}
+
+// gridOverhead is the width taken by borders and padding.
+func gridOverhead(columns int, framed bool) int {
+	if framed {
+		return 3*columns + 1
+	}
+	return 2 * (columns - 1)
+}
+
+// fitWidths narrows the widest columns, one cell at a time, until their
+// sum fits in available. Columns never get narrower than a few cells, so a
+// very narrow terminal still overflows.
+func fitWidths(widths []int, available int) {
+	const minWidth = 4
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+	for total > available {
+		widest := 0
+		for i, width := range widths {
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minWidth {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+// truncate shortens s to at most width terminal cells, ending it with
+// ellipsis when anything was cut. A wide character that would straddle the
+// limit is dropped, leaving the cell a cell short.
+func truncate(s string, width int, ellipsis string) string {
+	if textWidth(s) <= width {
+		return s
+	}
+	room := width - textWidth(ellipsis)
+	var b strings.Builder
+	for _, r := range s {
+		w := runeWidth(r)
+		if w > room {
+			break
+		}
+		room -= w
+		b.WriteRune(r)
+	}
+	return b.String() + ellipsis
+}
+
+// textWidth returns the number of terminal cells s takes.
+func textWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth returns the number of terminal cells r takes: two for East
+// Asian wide and fullwidth characters, such as CJK ideographs and most
+// emoji, none for combining marks and other invisible characters, and one
+// otherwise.
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,67 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTextWidth(t *testing.T) {
+	tests := map[string]int{
+		"Frankfurt":  9,
+		"東京":         4,
+		"ｆｕｌｌ":       8,
+		"caf\u00e9":  4,
+		"cafe\u0301": 4,
+		"🇯🇵":         2,
+		"":           0,
+	}
+	for s, want := range tests {
+		if got := textWidth(s); got != want {
+			t.Errorf("textWidth(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"Frankfurt", 9, "Frankfurt"},
+		{"Frankfurt", 6, "Frank…"},
+		{"東京都千代田区", 14, "東京都千代田区"},
+		{"東京都千代田区", 7, "東京都…"},
+		// 都 would straddle the limit, so the cell is one short.
+		{"東京都千代田区", 6, "東京…"},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.s, tt.width, "…")
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+		if textWidth(got) > tt.width {
+			t.Errorf("truncate(%q, %d) is %d cells wide", tt.s, tt.width, textWidth(got))
+		}
+	}
+}
+
+func TestGridAlignsWideCharacters(t *testing.T) {
+	var out strings.Builder
+	doc := &Document{
+		Data: []interface{}{
+			map[string]interface{}{"ip": "1.1.1.1", "city": "東京"},
+			map[string]interface{}{"ip": "8.8.8.8", "city": "Frankfurt am Main"},
+		},
+		Width: 30,
+	}
+	if err := renderGrid(&out, doc); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	for _, line := range lines {
+		if w := textWidth(line); w != textWidth(lines[0]) || w > doc.Width {
+			t.Errorf("line %q is %d cells wide, want %d (at most %d)", line, w, textWidth(lines[0]), doc.Width)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package output renders API responses in the formats selected with the
+// --output flag. Formats are kept in a registry, so that every command
+// supports every format and a new format only needs to be registered once.
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Default is the format used when none is selected.
+const Default = "pretty"
+
+// Help is the --output value that lists the available formats.
+const Help = "help"
+
+// DefaultArraySeparator joins array values in the tabular formats. It is
+// not a comma, so that CSV cells holding arrays need no quoting.
+const DefaultArraySeparator = ";"
+
+// Document is a decoded API response to render.
+type Document struct {
+	// Name identifies the endpoint, e.g. "ipgeo" or "ipgeo-bulk".
+	Name string
+	// Raw is the response body as returned by the API.
+	Raw []byte
+	// Data is the decoded body: a *client.OrderedMap, a
+	// map[string]interface{}, a []interface{} or a scalar, as produced by
+	// client.DecodeJSON or encoding/json.
+	Data interface{}
+
+	// Columns selects and orders, by their dotted names, the columns of the
+	// tabular formats and of the table grid; none selects every column.
+	Columns []string
+	// ArraySeparator joins array values into one cell in the tabular
+	// formats; empty selects DefaultArraySeparator.
+	ArraySeparator string
+	// Border is the border style of the table grid: BorderBox (the
+	// default), BorderASCII or BorderNone.
+	Border string
+	// Width is the width the table grid should fit in, such as that of the
+	// terminal; 0 leaves it unbounded.
+	Width int
+}
+
+// Renderer writes a Document in one format.
+type Renderer interface {
+	Render(w io.Writer, doc *Document) error
+}
+
+// RendererFunc adapts a function to the Renderer interface.
+type RendererFunc func(w io.Writer, doc *Document) error
+
+// Render calls f.
+func (f RendererFunc) Render(w io.Writer, doc *Document) error {
+	return f(w, doc)
+}
+
+// Format is a registered output format.
+type Format struct {
+	Name        string
+	Description string
+	Renderer    Renderer
+}
+
+var formats = map[string]Format{}
+
+// Register adds a format to the registry. It panics if the name is taken,
+// as that is a programming error.
+func Register(name, description string, r Renderer) {
+	if _, ok := formats[name]; ok || name == Help {
+		panic("output: format " + name + " registered twice")
+	}
+	formats[name] = Format{Name: name, Description: description, Renderer: r}
+}
+
+// Formats returns the registered formats sorted by name.
+func Formats() []Format {
+	list := make([]Format, 0, len(formats))
+	for _, f := range formats {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Names returns the names of the registered formats, sorted.
+func Names() []string {
+	var names []string
+	for _, f := range Formats() {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// Lookup returns the renderer of the named format; an empty name selects
+// Default.
+func Lookup(name string) (Renderer, error) {
+	if name == "" {
+		name = Default
+	}
+	f, ok := formats[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q; available formats: %s", name, strings.Join(Names(), ", "))
+	}
+	return f.Renderer, nil
+}
+
+// Render writes doc to w in the named format.
+func Render(w io.Writer, name string, doc *Document) error {
+	r, err := Lookup(name)
+	if err != nil {
+		return err
+	}
+	return r.Render(w, doc)
+}
@@ -0,0 +1,168 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/IPGeolocation/cli/v2/client"
+	"github.com/IPGeolocation/cli/v2/internal/utils"
+)
+
+// table is a response flattened for the tabular formats: one row per
+// result, with nested fields as dotted column names.
+type table struct {
+	// columns is the union of the columns of every row, in the order they
+	// were first seen, or the selected columns.
+	columns []string
+	rows    []map[string]string
+}
+
+// flatten turns doc.Data into a table. An array gives one row per element,
+// anything else a single row.
+func flatten(doc *Document) *table {
+	sep := doc.ArraySeparator
+	if sep == "" {
+		sep = DefaultArraySeparator
+	}
+	items, ok := doc.Data.([]interface{})
+	if !ok {
+		items = []interface{}{doc.Data}
+	}
+
+	t := &table{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		row := map[string]string{}
+		var order []string
+		flattenValue(row, &order, "", item, sep)
+		for _, column := range order {
+			if !seen[column] {
+				seen[column] = true
+				t.columns = append(t.columns, column)
+			}
+		}
+		t.rows = append(t.rows, row)
+	}
+	if len(doc.Columns) > 0 {
+		t.columns = selectColumns(t.columns, doc.Columns)
+	}
+	return t
+}
+
+// flattenValue adds value to row under key, descending into objects. The
+// columns are appended to order as they are added.
+func flattenValue(row map[string]string, order *[]string, key string, value interface{}, sep string) {
+	if keys, values, ok := utils.Members(value); ok && len(keys) > 0 {
+		for _, k := range keys {
+			name := k
+			if key != "" {
+				name = key + "." + k
+			}
+			flattenValue(row, order, name, values[k], sep)
+		}
+		return
+	}
+	if key == "" {
+		key = "value"
+	}
+	row[key] = cell(value, sep)
+	*order = append(*order, key)
+}
+
+// cell formats a value for a single cell. Arrays are joined with sep;
+// objects and arrays nested in arrays are written as compact JSON.
+func cell(value interface{}, sep string) string {
+	switch v := value.(type) {
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			switch item.(type) {
+			case *client.OrderedMap, map[string]interface{}, []interface{}:
+				parts[i] = compactJSON(item)
+			default:
+				parts[i] = cell(item, sep)
+			}
+		}
+		return strings.Join(parts, sep)
+	case *client.OrderedMap, map[string]interface{}:
+		return compactJSON(v)
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func compactJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// selectColumns returns the columns named in wanted, in that order. A name
+// also selects the columns nested under it, so "location" selects
+// "location.city" and its siblings. A name matching no column is kept, and
+// printed empty, so that the header does not depend on the response.
+func selectColumns(available, wanted []string) []string {
+	var selected []string
+	seen := map[string]bool{}
+	add := func(column string) {
+		if !seen[column] {
+			seen[column] = true
+			selected = append(selected, column)
+		}
+	}
+	for _, name := range wanted {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		matched := false
+		for _, column := range available {
+			if column == name || strings.HasPrefix(column, name+".") {
+				add(column)
+				matched = true
+			}
+		}
+		if !matched {
+			add(name)
+		}
+	}
+	return selected
+}
+
+// renderDelimited returns a renderer writing a table as delimited values
+// with a header row.
+func renderDelimited(comma rune) RendererFunc {
+	return func(w io.Writer, doc *Document) error {
+		t := flatten(doc)
+		cw := csv.NewWriter(w)
+		cw.Comma = comma
+		if err := cw.Write(t.columns); err != nil {
+			return err
+		}
+		record := make([]string, len(t.columns))
+		for _, row := range t.rows {
+			for i, column := range t.columns {
+				record[i] = row[column]
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+}
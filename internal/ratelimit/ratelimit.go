@@ -0,0 +1,173 @@
+// Package ratelimit paces API requests with a token bucket, so that bulk
+// and parallel workloads stay under the account's rate limit instead of
+// running into 429 responses.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket holding up to Burst tokens and refilled at Rate
+// tokens per second. Each request takes a token, waiting for one when the
+// bucket is empty.
+//
+// A Limiter is safe for concurrent use. When File is set, the bucket is kept
+// in that file under an exclusive lock, so that concurrent processes using
+// the same file share it. If the file cannot be used, the Limiter falls
+// back to a bucket of its own rather than failing requests.
+type Limiter struct {
+	Rate  float64
+	Burst int
+	// File, when set, holds the bucket shared between processes.
+	File string
+	// Logf, when set, is told when File cannot be used.
+	Logf func(format string, args ...interface{})
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// New returns a Limiter allowing rate requests per second with bursts of
+// up to burst requests. A burst below 1 is treated as 1.
+func New(rate float64, burst int) *Limiter {
+	return &Limiter{Rate: rate, Burst: burst}
+}
+
+// bucket is the state of a Limiter, as kept in its File.
+type bucket struct {
+	Tokens float64   `json:"tokens"`
+	Last   time.Time `json:"last"`
+}
+
+// Wait takes a token, blocking until one is available or ctx is done, and
+// returns how long it waited.
+func (l *Limiter) Wait(ctx context.Context) (time.Duration, error) {
+	delay := l.reserve(time.Now())
+	if delay <= 0 {
+		return 0, nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// reserve takes a token at now and returns how long the caller must wait
+// before using it. The bucket may go negative; the debt is what later
+// callers wait for.
+func (l *Limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.File != "" {
+		delay, err := l.reserveShared(now)
+		if err == nil {
+			return delay
+		}
+		if l.Logf != nil {
+			l.Logf("%v; limiting this process only", err)
+		}
+		l.File = ""
+	}
+
+	b := bucket{Tokens: l.tokens, Last: l.last}
+	delay := l.take(&b, now)
+	l.tokens, l.last = b.Tokens, b.Last
+	return delay
+}
+
+// reserveShared is reserve for the bucket kept in File.
+func (l *Limiter) reserveShared(now time.Time) (time.Duration, error) {
+	var delay time.Duration
+	err := withLockedFile(l.File, func(f *os.File) error {
+		var b bucket
+		if err := json.NewDecoder(f).Decode(&b); err != nil {
+			// A new or damaged file starts with a full bucket.
+			b = bucket{}
+		}
+		delay = l.take(&b, now)
+		data, _ := json.Marshal(b)
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		_, err := f.WriteAt(data, 0)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("rate limit state %s: %w", l.File, err)
+	}
+	return delay, nil
+}
+
+// take refills b for the time elapsed since its last use, takes a token
+// and returns the time until that token is actually available.
+func (l *Limiter) take(b *bucket, now time.Time) time.Duration {
+	burst := math.Max(float64(l.Burst), 1)
+	if b.Last.IsZero() {
+		b.Tokens = burst
+	} else if elapsed := now.Sub(b.Last).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+elapsed*l.Rate)
+	}
+	b.Last = now
+	b.Tokens--
+	if b.Tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.Tokens / l.Rate * float64(time.Second))
+}
+
+// withLockedFile opens path, creating it and its directory if needed, and
+// calls fn with the file locked exclusively.
+func withLockedFile(path string, fn func(*os.File) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+	return fn(f)
+}
+
+// Transport is an http.RoundTripper taking a token from Limiter before each
+// request sent by Base.
+type Transport struct {
+	Base    http.RoundTripper
+	Limiter *Limiter
+	// Logf, when set, is told how long a request was held back.
+	Logf func(format string, args ...interface{})
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	waited, err := t.Limiter.Wait(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	if waited > 0 && t.Logf != nil {
+		t.Logf("rate limit: waited %s before %s %s", waited.Round(time.Millisecond), req.Method, req.URL.Path)
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 6, 21, 12, 0, 0, 0, time.UTC)
+
+// at returns the fake time offset by d from start.
+func at(d time.Duration) time.Time {
+	return start.Add(d)
+}
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		// takes are the times of successive reservations and the delays
+		// they must get.
+		takes []struct{ at, want time.Duration }
+	}{
+		{
+			name: "burst, then one every 1/rate seconds", rate: 2, burst: 3,
+			takes: []struct{ at, want time.Duration }{
+				{0, 0}, {0, 0}, {0, 0},
+				{0, 500 * time.Millisecond},
+				{0, time.Second},
+				{time.Second, 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "refills up to the burst only", rate: 10, burst: 2,
+			takes: []struct{ at, want time.Duration }{
+				{0, 0}, {0, 0},
+				{time.Hour, 0}, {time.Hour, 0},
+				{time.Hour, 100 * time.Millisecond},
+			},
+		},
+		{
+			name: "partial refill", rate: 1, burst: 1,
+			takes: []struct{ at, want time.Duration }{
+				{0, 0},
+				{250 * time.Millisecond, 750 * time.Millisecond},
+				{2 * time.Second, 0},
+			},
+		},
+		{
+			name: "burst below 1 counts as 1", rate: 4, burst: 0,
+			takes: []struct{ at, want time.Duration }{
+				{0, 0},
+				{0, 250 * time.Millisecond},
+			},
+		},
+		{
+			name: "clock going backwards adds no tokens", rate: 1, burst: 1,
+			takes: []struct{ at, want time.Duration }{
+				{time.Second, 0},
+				{0, time.Second},
+			},
+		},
+	}
+	for _, tt := range tests {
+		l := New(tt.rate, tt.burst)
+		for i, take := range tt.takes {
+			if got := l.reserve(at(take.at)); got != take.want {
+				t.Errorf("%s: take %d at %s waits %s, want %s", tt.name, i+1, take.at, got, take.want)
+			}
+		}
+	}
+}
+
+func TestSharedFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ratelimit", "bucket.json")
+	a := &Limiter{Rate: 1, Burst: 2, File: file}
+	b := &Limiter{Rate: 1, Burst: 2, File: file}
+	var logs []string
+	a.Logf = func(format string, args ...interface{}) { logs = append(logs, format) }
+	b.Logf = a.Logf
+
+	if got := a.reserve(at(0)); got != 0 {
+		t.Errorf("first take waits %s", got)
+	}
+	if got := b.reserve(at(0)); got != 0 {
+		t.Errorf("second take waits %s", got)
+	}
+	if got := a.reserve(at(0)); got != time.Second {
+		t.Errorf("third take waits %s; the bucket is not shared", got)
+	}
+	if got := b.reserve(at(0)); got != 2*time.Second {
+		t.Errorf("fourth take waits %s; the bucket is not shared", got)
+	}
+	if len(logs) > 0 {
+		t.Errorf("unexpected fallback: %q", logs)
+	}
+
+	// A damaged file starts over with a full bucket.
+	if err := os.WriteFile(file, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.reserve(at(0)); got != 0 {
+		t.Errorf("take after damage waits %s", got)
+	}
+}
+
+func TestFallbackWhenFileUnusable(t *testing.T) {
+	// A regular file where the bucket's directory should be makes the
+	// shared bucket unusable, even for root.
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "ratelimit")
+	if err := os.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	var logs []string
+	l := &Limiter{Rate: 1, Burst: 1, File: filepath.Join(blocker, "bucket.json")}
+	l.Logf = func(format string, args ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}
+
+	if got := l.reserve(at(0)); got != 0 {
+		t.Errorf("first take waits %s", got)
+	}
+	if got := l.reserve(at(0)); got != time.Second {
+		t.Errorf("second take waits %s; the fallback bucket does not limit", got)
+	}
+	if len(logs) != 1 || !strings.Contains(logs[0], "limiting this process only") {
+		t.Errorf("logged %q, want one fallback message", logs)
+	}
+	if l.File != "" {
+		t.Errorf("File = %q after the fallback", l.File)
+	}
+}
+
+func TestWait(t *testing.T) {
+	l := New(1000, 1)
+	if waited, err := l.Wait(context.Background()); err != nil || waited != 0 {
+		t.Errorf("first Wait = %s, %v", waited, err)
+	}
+	if waited, err := l.Wait(context.Background()); err != nil || waited <= 0 || waited > time.Millisecond {
+		t.Errorf("second Wait = %s, %v; want up to 1ms", waited, err)
+	}
+
+	slow := New(0.001, 1)
+	slow.Wait(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := slow.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait with a canceled context: got %v", err)
+	}
+}
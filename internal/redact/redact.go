@@ -0,0 +1,133 @@
+// Package redact removes secrets such as API keys from text before it is
+// shown to the user or written to logs.
+package redact
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every redacted secret.
+const Mask = "[REDACTED]"
+
+// minLength keeps very short values from being registered, since masking
+// them would mangle unrelated text.
+const minLength = 4
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// Add registers a secret to be removed by String and the other helpers.
+// Its URL-encoded forms are registered as well.
+func Add(secret string) {
+	if len(secret) < minLength {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, s := range []string{secret, url.QueryEscape(secret), url.PathEscape(secret)} {
+		if !contains(secrets, s) {
+			secrets = append(secrets, s)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns s with every registered secret masked.
+func String(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	return s
+}
+
+// Error wraps err so that its message has every registered secret masked.
+// errors.Is and errors.As still see the original error.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err}
+}
+
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return String(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// Writer returns a writer that masks registered secrets in what is written
+// to w. Output that ends with the start of a secret is held back until the
+// next Write shows whether the secret follows, so secrets split across
+// writes are masked too; call Flush once writing is done.
+func Writer(w io.Writer) *MaskWriter {
+	return &MaskWriter{w: w}
+}
+
+// MaskWriter is the writer returned by Writer.
+type MaskWriter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	held string
+}
+
+// Write masks p, together with any output held back from earlier writes,
+// and writes everything that cannot be the start of a secret to w.
+func (w *MaskWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := String(w.held + string(p))
+	n := len(s) - partialSecret(s)
+	w.held = s[n:]
+	if _, err := io.WriteString(w.w, s[:n]); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes any output still held back.
+func (w *MaskWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := String(w.held)
+	w.held = ""
+	_, err := io.WriteString(w.w, s)
+	return err
+}
+
+// partialSecret returns the length of the longest suffix of s that is the
+// start, but not the whole, of a registered secret.
+func partialSecret(s string) int {
+	mu.RLock()
+	defer mu.RUnlock()
+	longest := 0
+	for _, secret := range secrets {
+		for n := len(secret) - 1; n > longest; n-- {
+			if strings.HasSuffix(s, secret[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
@@ -0,0 +1,104 @@
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// key contains characters that URL encoding changes, so the escaped forms
+// differ from the key itself.
+const key = "k3y/with+special=chars"
+
+func init() {
+	Add(key)
+}
+
+func TestShortValuesAreIgnored(t *testing.T) {
+	Add("ab")
+	if got := String("a table"); got != "a table" {
+		t.Errorf("String = %q; a short value was masked", got)
+	}
+}
+
+func TestError(t *testing.T) {
+	base := &fs.PathError{Op: "open", Path: "/tmp/" + key, Err: fs.ErrNotExist}
+	err := Error(fmt.Errorf("reading key: %w", base))
+	if strings.Contains(err.Error(), key) || !strings.Contains(err.Error(), Mask) {
+		t.Errorf("Error() = %q; key not masked", err.Error())
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("errors.Is does not see the wrapped error")
+	}
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) {
+		t.Error("errors.As does not see the wrapped error")
+	}
+	if Error(nil) != nil {
+		t.Error("Error(nil) != nil")
+	}
+}
+
+func TestURLs(t *testing.T) {
+	u := url.URL{Scheme: "https", Host: "api.example.com", Path: "/v2/ipgeo/" + key}
+	u.RawQuery = url.Values{"apiKey": {key}, "ip": {"8.8.8.8"}}.Encode()
+
+	for _, s := range []string{u.String(), url.QueryEscape(key), url.PathEscape(key)} {
+		got := String(s)
+		if strings.Contains(got, key) || strings.Contains(got, url.QueryEscape(key)) || strings.Contains(got, url.PathEscape(key)) {
+			t.Errorf("String(%q) = %q; key not masked", s, got)
+		}
+	}
+	err := Error(&url.Error{Op: "Get", URL: u.String(), Err: errors.New("connection refused")})
+	if strings.Contains(err.Error(), url.QueryEscape(key)) {
+		t.Errorf("Error() = %q; key not masked", err.Error())
+	}
+}
+
+func TestWriterSplitWrites(t *testing.T) {
+	line := "GET /v2/ipgeo?apiKey=" + url.QueryEscape(key) + "&ip=8.8.8.8 key=" + key + "\n"
+	want := String(line)
+
+	for size := 1; size <= len(line); size++ {
+		var buf bytes.Buffer
+		w := Writer(&buf)
+		for p := line; p != ""; {
+			n := size
+			if n > len(p) {
+				n = len(p)
+			}
+			if m, err := w.Write([]byte(p[:n])); err != nil || m != n {
+				t.Fatalf("Write = %d, %v; want %d, nil", m, err, n)
+			}
+			p = p[n:]
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("writes of %d bytes: got %q, want %q", size, buf.String(), want)
+		}
+	}
+}
+
+func TestWriterHoldsOnlyPossibleSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	w := Writer(&buf)
+	w.Write([]byte("Passphrase: "))
+	if buf.String() != "Passphrase: " {
+		t.Errorf("got %q before Flush; ordinary output was held back", buf.String())
+	}
+
+	w.Write([]byte("key " + key[:5]))
+	if got := buf.String(); strings.HasSuffix(got, key[:5]) {
+		t.Errorf("got %q; the start of the key was written before the rest arrived", got)
+	}
+	w.Flush()
+	if got := buf.String(); got != "Passphrase: key "+key[:5] {
+		t.Errorf("after Flush got %q; held output was lost", got)
+	}
+}
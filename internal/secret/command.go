@@ -0,0 +1,70 @@
+package secret
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// CommandStore runs shell commands to read and optionally write the key.
+// GetCommand must print the key on the first line of its output, as
+// `pass show` does. SetCommand, if any, receives the key on stdin.
+type CommandStore struct {
+	GetCommand string
+	SetCommand string
+}
+
+func (s *CommandStore) Get() (string, error) {
+	if s.GetCommand == "" {
+		return "", fmt.Errorf("command secret store has no command configured")
+	}
+	out, err := runShell(s.GetCommand, nil)
+	if err != nil {
+		return "", err
+	}
+	key := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	if key == "" {
+		return "", ErrNotFound
+	}
+	return key, nil
+}
+
+func (s *CommandStore) Set(key string) error {
+	if s.SetCommand == "" {
+		return ErrReadOnly
+	}
+	_, err := runShell(s.SetCommand, strings.NewReader(key+"\n"))
+	return err
+}
+
+func (s *CommandStore) Delete() error {
+	return ErrReadOnly
+}
+
+func (s *CommandStore) String() string {
+	return BackendCommand + " `" + s.GetCommand + "`"
+}
+
+// runShell runs command through the platform shell and returns its stdout.
+func runShell(command string, stdin *strings.Reader) ([]byte, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("secret command %q failed: %w", command, err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,91 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/IPGeolocation/cli/v2/internal/utils"
+)
+
+// EncryptedStore keeps the key encrypted in a string field of the config
+// file. Callers must save the config after Set or Delete.
+type EncryptedStore struct {
+	Value *string
+
+	// Scheme is the key scheme new values are encrypted with, either
+	// utils.SchemeMachine (the default) or utils.SchemePassphrase.
+	Scheme string
+	// Passphrase returns the passphrase for the passphrase scheme. It is
+	// only called when one is needed.
+	Passphrase func() (string, error)
+}
+
+func (s *EncryptedStore) Get() (string, error) {
+	if *s.Value == "" {
+		return "", ErrNotFound
+	}
+
+	passphrase := ""
+	if utils.EnvelopeScheme(*s.Value) == utils.SchemePassphrase {
+		var err error
+		if passphrase, err = s.passphrase(); err != nil {
+			return "", err
+		}
+	}
+	plain, err := utils.DecryptStringWithPassphrase(*s.Value, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+func (s *EncryptedStore) Set(key string) error {
+	var encrypted string
+	var err error
+	if s.Scheme == utils.SchemePassphrase {
+		passphrase, perr := s.passphrase()
+		if perr != nil {
+			return perr
+		}
+		encrypted, err = utils.EncryptStringWithPassphrase(key, passphrase)
+	} else {
+		encrypted, err = utils.EncryptString(key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encrypt API key: %w", err)
+	}
+	*s.Value = encrypted
+	return nil
+}
+
+func (s *EncryptedStore) Delete() error {
+	*s.Value = ""
+	return nil
+}
+
+func (s *EncryptedStore) String() string {
+	return BackendEncrypted + " config file"
+}
+
+func (s *EncryptedStore) passphrase() (string, error) {
+	if s.Passphrase == nil {
+		return "", utils.ErrPassphraseRequired
+	}
+	passphrase, err := s.Passphrase()
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", utils.ErrPassphraseRequired
+	}
+	return passphrase, nil
+}
+
+// ValidateScheme reports whether scheme is a known key scheme for the
+// encrypted store.
+func ValidateScheme(scheme string) error {
+	switch scheme {
+	case utils.SchemeMachine, utils.SchemePassphrase:
+		return nil
+	}
+	return fmt.Errorf("unknown key scheme %q; use %s or %s", scheme, utils.SchemeMachine, utils.SchemePassphrase)
+}
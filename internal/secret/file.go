@@ -0,0 +1,72 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// FileStore keeps the key in plain text in a file with 0600 permissions.
+type FileStore struct {
+	Path string
+}
+
+func (s *FileStore) Get() (string, error) {
+	info, err := os.Stat(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	// Like ssh, refuse keys that other users can read.
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("permissions %04o for %s are too open; run: chmod 600 %s", info.Mode().Perm(), s.Path, s.Path)
+	}
+
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return "", err
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", ErrNotFound
+	}
+	return key, nil
+}
+
+func (s *FileStore) Set(key string) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.Path, []byte(key+"\n"), 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file.
+	return os.Chmod(s.Path, 0600)
+}
+
+func (s *FileStore) Delete() error {
+	err := os.Remove(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileStore) String() string {
+	return BackendFile + " " + s.Path
+}
+
+// ValidateName rejects profile names that are unsafe in a file name, as the
+// file store keeps a profile's key in <name>.key next to the config file by
+// default.
+func ValidateName(name string) error {
+	if name == "" || strings.Contains(name, "..") || strings.ContainsAny(name, `/\:`+"\x00") {
+		return fmt.Errorf("invalid profile name %q: it must not be empty or contain '/', '\\', ':' or '..'", name)
+	}
+	return nil
+}
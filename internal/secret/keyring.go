@@ -0,0 +1,37 @@
+package secret
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultKeyringService is the service name keys are stored under.
+const DefaultKeyringService = "ipgeolocation"
+
+// KeyringStore keeps the key in the operating system keyring. It shells
+// out to secret-tool on Linux and security on macOS, so no desktop
+// libraries are linked into the binary.
+type KeyringStore struct {
+	Service string
+	Account string
+}
+
+func (s *KeyringStore) service() string {
+	if s.Service == "" {
+		return DefaultKeyringService
+	}
+	return s.Service
+}
+
+func (s *KeyringStore) String() string {
+	return BackendKeyring + " " + s.service() + "/" + s.Account
+}
+
+// toolError describes a failed keyring tool invocation, including whatever
+// the tool printed.
+func toolError(tool string, err error, out []byte) error {
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return fmt.Errorf("%s failed: %w: %s", tool, err, msg)
+	}
+	return fmt.Errorf("%s failed: %w", tool, err)
+}
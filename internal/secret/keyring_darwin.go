@@ -0,0 +1,65 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// macOS keyring access goes through the security tool and the login keychain.
+
+// errItemNotFound is the exit status security uses for a missing item.
+const errItemNotFound = 44
+
+func (s *KeyringStore) Get() (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", s.service(), "-a", s.Account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == errItemNotFound {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("security find-generic-password failed: %w", err)
+	}
+	key := strings.TrimSpace(string(out))
+	if key == "" {
+		return "", ErrNotFound
+	}
+	return key, nil
+}
+
+// Set runs add-generic-password through `security -i`, which reads the
+// command from stdin, so that the key never appears in the argument list
+// visible to other users in ps.
+func (s *KeyringStore) Set(key string) error {
+	if strings.ContainsAny(key, "\r\n") {
+		return errors.New("the API key must not contain line breaks")
+	}
+	line := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", quote(s.service()), quote(s.Account), quote(key))
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(line)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return toolError("security add-generic-password", err, out)
+	}
+	// In interactive mode, security exits 0 even when the command fails, so
+	// read the key back to be sure it was stored.
+	if stored, err := s.Get(); err != nil || stored != key {
+		return toolError("security add-generic-password", errors.New("the key was not stored"), out)
+	}
+	return nil
+}
+
+// quote quotes an argument for a `security -i` command line.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (s *KeyringStore) Delete() error {
+	err := exec.Command("security", "delete-generic-password", "-s", s.service(), "-a", s.Account).Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == errItemNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Linux keyring access goes through secret-tool from libsecret, which talks
+// to any Secret Service provider (GNOME Keyring, KWallet, KeePassXC).
+
+func (s *KeyringStore) Get() (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", s.service(), "account", s.Account).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) == 0 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("secret-tool lookup failed: %w", err)
+	}
+	key := strings.TrimSpace(string(out))
+	if key == "" {
+		return "", ErrNotFound
+	}
+	return key, nil
+}
+
+func (s *KeyringStore) Set(key string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=ipgeolocation API key ("+s.Account+")",
+		"service", s.service(), "account", s.Account)
+	cmd.Stdin = strings.NewReader(key)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return toolError("secret-tool store", err, out)
+	}
+	return nil
+}
+
+func (s *KeyringStore) Delete() error {
+	if out, err := exec.Command("secret-tool", "clear", "service", s.service(), "account", s.Account).CombinedOutput(); err != nil {
+		return toolError("secret-tool clear", err, out)
+	}
+	return nil
+}
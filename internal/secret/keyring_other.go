@@ -0,0 +1,25 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package secret
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func (s *KeyringStore) Get() (string, error) {
+	return "", errKeyringUnsupported()
+}
+
+func (s *KeyringStore) Set(key string) error {
+	return errKeyringUnsupported()
+}
+
+func (s *KeyringStore) Delete() error {
+	return errKeyringUnsupported()
+}
+
+func errKeyringUnsupported() error {
+	return fmt.Errorf("the keyring secret store is not supported on %s; use the file or command store instead", runtime.GOOS)
+}
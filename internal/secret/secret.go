@@ -0,0 +1,54 @@
+// Package secret stores and retrieves API keys using pluggable backends.
+package secret
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Backend names accepted in the config file.
+const (
+	// BackendEncrypted keeps the key AES-GCM encrypted inside config.json.
+	BackendEncrypted = "encrypted"
+	// BackendFile keeps the key in plain text in a file readable only by
+	// its owner.
+	BackendFile = "file"
+	// BackendCommand runs an external command, e.g. `pass show ipgeo`,
+	// and reads the key from its output.
+	BackendCommand = "command"
+	// BackendKeyring uses the operating system keyring (Secret Service on
+	// Linux, Keychain on macOS).
+	BackendKeyring = "keyring"
+)
+
+// Backends lists every supported backend name.
+var Backends = []string{BackendEncrypted, BackendFile, BackendCommand, BackendKeyring}
+
+var (
+	// ErrNotFound is returned by Get when the store holds no key.
+	ErrNotFound = errors.New("no API key stored")
+	// ErrReadOnly is returned by Set and Delete on stores that cannot be written.
+	ErrReadOnly = errors.New("secret store is read-only")
+)
+
+// Store keeps a single API key.
+type Store interface {
+	// Get returns the stored key, or ErrNotFound.
+	Get() (string, error)
+	// Set replaces the stored key.
+	Set(key string) error
+	// Delete removes the stored key. Deleting a missing key is not an error.
+	Delete() error
+	// String describes the store for display, e.g. "file ~/.ipgeo/key".
+	String() string
+}
+
+// ValidateBackend checks that name is a supported backend.
+func ValidateBackend(name string) error {
+	for _, b := range Backends {
+		if name == b {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown secret store %q (available: encrypted, file, command, keyring)", name)
+}
@@ -0,0 +1,169 @@
+package secret
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "work.key")
+	s := &FileStore{Path: path}
+
+	if _, err := s.Get(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get on a missing file: got %v, want ErrNotFound", err)
+	}
+	if err := s.Set("k-123"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode %04o, want 0600", info.Mode().Perm())
+	}
+	if key, err := s.Get(); err != nil || key != "k-123" {
+		t.Errorf("Get = %q, %v; want k-123", key, err)
+	}
+
+	if err := s.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(); err != nil {
+		t.Errorf("deleting a missing key: %v", err)
+	}
+	if _, err := s.Get(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileStoreRefusesReadableFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+	for _, mode := range []os.FileMode{0640, 0604, 0644, 0660} {
+		path := filepath.Join(t.TempDir(), "api.key")
+		if err := os.WriteFile(path, []byte("k-123\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+		_, err := (&FileStore{Path: path}).Get()
+		if err == nil || !strings.Contains(err.Error(), "too open") {
+			t.Errorf("mode %04o: got %v, want a permissions error", mode, err)
+		}
+	}
+}
+
+func TestFileStoreSetTightensMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "api.key")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := &FileStore{Path: path}
+	if err := s.Set("new"); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := s.Get(); err != nil || key != "new" {
+		t.Errorf("Get = %q, %v; want new", key, err)
+	}
+}
+
+func TestCommandStoreGet(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tests := []struct {
+		command string
+		want    string
+		err     error
+	}{
+		{`printf 'k-123\n'`, "k-123", nil},
+		{`printf '  k-123  \nlogin: me\nurl: example.com\n'`, "k-123", nil},
+		{`printf 'k-123'`, "k-123", nil},
+		{`printf '\nk-123\n'`, "", ErrNotFound},
+		{`true`, "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		key, err := (&CommandStore{GetCommand: tt.command}).Get()
+		if key != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %q, %v; want %q, %v", tt.command, key, err, tt.want, tt.err)
+		}
+	}
+
+	if _, err := (&CommandStore{GetCommand: "exit 3"}).Get(); err == nil {
+		t.Error("a failing command must fail Get")
+	}
+	if _, err := (&CommandStore{}).Get(); err == nil {
+		t.Error("a store without a command must fail Get")
+	}
+}
+
+func TestCommandStoreSet(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	out := filepath.Join(t.TempDir(), "stdin")
+	s := &CommandStore{GetCommand: "cat " + out, SetCommand: "cat > " + out}
+	if err := s.Set("k-123"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "k-123\n" {
+		t.Errorf("set command got %q on stdin, want %q", data, "k-123\n")
+	}
+	if key, err := s.Get(); err != nil || key != "k-123" {
+		t.Errorf("Get = %q, %v; want k-123", key, err)
+	}
+
+	readOnly := &CommandStore{GetCommand: "echo k"}
+	if err := readOnly.Set("k"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Set without a set command: got %v, want ErrReadOnly", err)
+	}
+	if err := readOnly.Delete(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Delete: got %v, want ErrReadOnly", err)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := map[string]bool{
+		"work":        true,
+		"prod-eu_1":   true,
+		"a.b":         true,
+		"":            false,
+		"../x":        false,
+		"..":          false,
+		"a..b":        false,
+		"a/b":         false,
+		`a\b`:         false,
+		"C:key":       false,
+		"nul\x00byte": false,
+	}
+	for name, valid := range tests {
+		if err := ValidateName(name); (err == nil) != valid {
+			t.Errorf("ValidateName(%q) = %v, want valid=%v", name, err, valid)
+		}
+	}
+}
+
+func TestValidateBackend(t *testing.T) {
+	for _, b := range Backends {
+		if err := ValidateBackend(b); err != nil {
+			t.Errorf("ValidateBackend(%q) = %v", b, err)
+		}
+	}
+	if err := ValidateBackend("vault"); err == nil {
+		t.Error("ValidateBackend accepted an unknown backend")
+	}
+}
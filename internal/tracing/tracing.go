@@ -0,0 +1,132 @@
+// Package tracing logs the HTTP traffic of the CLI for troubleshooting and
+// can record it as a HAR file.
+package tracing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultBodyLimit is how many bytes of each body are logged by default.
+const DefaultBodyLimit = 4096
+
+// Transport is an http.RoundTripper that logs every request and response
+// it passes to Base.
+type Transport struct {
+	// Base sends the requests. http.DefaultTransport is used when nil.
+	Base http.RoundTripper
+	// Logf receives the log lines. Nothing is logged when nil.
+	Logf func(format string, args ...interface{})
+	// Bodies also logs request and response bodies, up to BodyLimit bytes
+	// each. A BodyLimit of 0 or less logs them in full.
+	Bodies    bool
+	BodyLimit int
+	// HAR, when set, records every exchange.
+	HAR *HAR
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.logf("> %s %s", req.Method, req.URL)
+	t.logHeaders(">", req.Header)
+	t.logf(">   body: %d bytes", len(reqBody))
+	if t.Bodies && len(reqBody) > 0 {
+		t.logBody(">", reqBody)
+	}
+
+	start := time.Now()
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		elapsed := time.Since(start)
+		t.logf("< %s %s failed after %s: %v", req.Method, req.URL.Path, elapsed.Round(time.Millisecond), err)
+		t.HAR.add(req, reqBody, nil, nil, start, elapsed)
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	elapsed := time.Since(start)
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if err != nil {
+		return nil, err
+	}
+
+	t.logf("< %s in %s, %d bytes", resp.Status, elapsed.Round(time.Millisecond), len(respBody))
+	t.logHeaders("<", resp.Header)
+	if t.Bodies && len(respBody) > 0 {
+		t.logBody("<", respBody)
+	}
+	t.HAR.add(req, reqBody, resp, respBody, start, elapsed)
+	return resp, nil
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *Transport) logf(format string, args ...interface{}) {
+	if t.Logf != nil {
+		t.Logf(format, args...)
+	}
+}
+
+func (t *Transport) logHeaders(prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t.logf("%s   %s: %s", prefix, name, strings.Join(header[name], ", "))
+	}
+}
+
+func (t *Transport) logBody(prefix string, body []byte) {
+	if t.BodyLimit > 0 && len(body) > t.BodyLimit {
+		// Cut before the character straddling the limit rather than in
+		// the middle of its UTF-8 encoding.
+		n := t.BodyLimit
+		for i := 0; i < utf8.UTFMax-1 && n > 0 && !utf8.RuneStart(body[n]); i++ {
+			n--
+		}
+		t.logf("%s   %s... (truncated, %d of %d bytes shown)", prefix, body[:n], n, len(body))
+		return
+	}
+	t.logf("%s   %s", prefix, body)
+}
+
+// requestBody returns a copy of the request body without consuming it.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLogBodyTruncation(t *testing.T) {
+	tests := []struct {
+		body  string
+		limit int
+		want  string
+	}{
+		{`{"city":"Zürich"}`, 0, `{"city":"Zürich"}`},
+		{`{"city":"Zürich"}`, 100, `{"city":"Zürich"}`},
+		{`{"city":"Zürich"}`, 10, `{"city":"Z... (truncated, 10 of 18 bytes shown)`},
+		// ü is two bytes; a limit ending inside it backs off before it.
+		{`{"city":"Zürich"}`, 11, `{"city":"Z... (truncated, 10 of 18 bytes shown)`},
+		{`{"city":"Zürich"}`, 12, `{"city":"Zü... (truncated, 12 of 18 bytes shown)`},
+		// 東 and 京 are three bytes each.
+		{`東京`, 1, `... (truncated, 0 of 6 bytes shown)`},
+		{`東京`, 2, `... (truncated, 0 of 6 bytes shown)`},
+		{`東京`, 3, `東... (truncated, 3 of 6 bytes shown)`},
+		{`東京`, 5, `東... (truncated, 3 of 6 bytes shown)`},
+		// 🌍 is four bytes.
+		{`a🌍b`, 4, `a... (truncated, 1 of 6 bytes shown)`},
+		{`a🌍b`, 5, `a🌍... (truncated, 5 of 6 bytes shown)`},
+		// Invalid UTF-8 is cut at the limit, backing off no further than
+		// one character could need.
+		{"\x80\x80\x80\x80\x80\x80", 5, "\x80\x80... (truncated, 2 of 6 bytes shown)"},
+	}
+	for _, tt := range tests {
+		var got string
+		tr := &Transport{
+			BodyLimit: tt.limit,
+			Logf:      func(format string, args ...interface{}) { got = fmt.Sprintf(format, args...) },
+		}
+		tr.logBody("<", []byte(tt.body))
+		got = strings.TrimPrefix(got, "<   ")
+		if got != tt.want {
+			t.Errorf("body %q, limit %d: logged %q, want %q", tt.body, tt.limit, got, tt.want)
+		}
+		if utf8.ValidString(tt.body) && !utf8.ValidString(got) {
+			t.Errorf("body %q, limit %d: logged invalid UTF-8 %q", tt.body, tt.limit, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package usage keeps track of the requests sent to the API, and the credits
+// they cost when a response header reports them, in a local ledger.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeaderCreditsCharged is the response header read for the credits a
+// request cost. The API does not document a header giving the cost of a
+// request, so responses without this one leave the credits unknown.
+const HeaderCreditsCharged = "X-Credits-Charged"
+
+// Record is one ledger entry: what a single CLI invocation sent and spent.
+type Record struct {
+	Time     time.Time `json:"time"`
+	Profile  string    `json:"profile"`
+	Command  string    `json:"command"`
+	Requests int       `json:"requests"`
+	Credits  float64   `json:"credits"`
+	// CreditsReported is false when no response carried a credit header,
+	// so that Credits is unknown rather than zero.
+	CreditsReported bool `json:"credits_reported"`
+	// Quota holds the last credit, quota and rate limit headers seen.
+	Quota map[string]string `json:"quota,omitempty"`
+}
+
+// isQuotaHeader reports whether a response header describes credits, quota
+// or rate limits.
+func isQuotaHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range []string{"credit", "quota", "ratelimit", "rate-limit"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Meter is an http.RoundTripper counting the requests sent by Base and the
+// credits charged for them. It is safe for concurrent use.
+type Meter struct {
+	Base http.RoundTripper
+
+	mu     sync.Mutex
+	record Record
+}
+
+// RoundTrip implements http.RoundTripper.
+func (m *Meter) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := m.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.record.Requests++
+	if value := resp.Header.Get(HeaderCreditsCharged); value != "" {
+		if credits, err := strconv.ParseFloat(value, 64); err == nil {
+			m.record.Credits += credits
+			m.record.CreditsReported = true
+		}
+	}
+	for name, values := range resp.Header {
+		if isQuotaHeader(name) && name != HeaderCreditsCharged && len(values) > 0 {
+			if m.record.Quota == nil {
+				m.record.Quota = map[string]string{}
+			}
+			m.record.Quota[name] = values[0]
+		}
+	}
+	return resp, nil
+}
+
+// Record returns the usage metered so far. Time, Profile and Command are
+// left for the caller to fill in.
+func (m *Meter) Record() Record {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := m.record
+	if r.Quota != nil {
+		r.Quota = make(map[string]string, len(m.record.Quota))
+		for k, v := range m.record.Quota {
+			r.Quota[k] = v
+		}
+	}
+	return r
+}
+
+// Ledger is an append-only file of Records, one JSON object per line.
+type Ledger struct {
+	Path string
+}
+
+// Append adds r to the ledger, creating the file if needed.
+func (l *Ledger) Append(r Record) error {
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(r)
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns the records made at or after since, oldest first. A missing
+// ledger has no records; lines that cannot be parsed are skipped.
+func (l *Ledger) Read(since time.Time) ([]Record, error) {
+	f, err := os.Open(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if !r.Time.Before(since) {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// Clear deletes the ledger.
+func (l *Ledger) Clear() error {
+	err := os.Remove(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Grouping keys for Summarize.
+const (
+	ByDay     = "day"
+	ByCommand = "command"
+	ByProfile = "profile"
+)
+
+// Total is the usage of one group of records.
+type Total struct {
+	Key         string
+	Invocations int
+	Requests    int
+	Credits     float64
+	// Unreported counts the invocations whose credits are unknown.
+	Unreported int
+}
+
+// Summarize groups records by day (in local time), command or profile and
+// returns the totals sorted by key.
+func Summarize(records []Record, by string) []Total {
+	totals := map[string]*Total{}
+	for _, r := range records {
+		var key string
+		switch by {
+		case ByCommand:
+			key = r.Command
+		case ByProfile:
+			key = r.Profile
+		default:
+			key = r.Time.Local().Format("2006-01-02")
+		}
+		t, ok := totals[key]
+		if !ok {
+			t = &Total{Key: key}
+			totals[key] = t
+		}
+		t.Invocations++
+		t.Requests += r.Requests
+		t.Credits += r.Credits
+		if !r.CreditsReported {
+			t.Unreported++
+		}
+	}
+
+	result := make([]Total, 0, len(totals))
+	for _, t := range totals {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
@@ -7,8 +7,56 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Key schemes used to encrypt API keys stored in the config file.
+const (
+	// SchemeMachine derives the key from the home directory. It needs no
+	// input but is neither secret nor portable to another user or machine.
+	SchemeMachine = "machine"
+	// SchemePassphrase derives the key from a passphrase with argon2id.
+	SchemePassphrase = "passphrase"
+	// SchemeLegacy marks values written before envelopes were versioned.
+	// They are decrypted like SchemeMachine and upgraded by `config rekey`.
+	SchemeLegacy = "legacy"
+)
+
+// Envelope format, version 2:
+//
+//	$ipg2$home$<base64 nonce+ciphertext>
+//	$ipg2$argon2id$m=<KiB>,t=<passes>,p=<threads>$<base64 salt>$<base64 nonce+ciphertext>
+//
+// Everything before the ciphertext is authenticated as additional data.
+// Version 1 values are a bare base64 string keyed on the home directory.
+const envelopePrefix = "$ipg2$"
+
+// argon2id parameters for new envelopes; old envelopes carry their own.
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+	argonSaltLen = 16
+
+	// Bounds on the parameters read from an envelope, so that a tampered
+	// config file cannot crash the CLI or make it allocate without limit.
+	argonMaxTime   = 64
+	argonMaxMemory = 1024 * 1024 // 1 GiB
+)
+
+var (
+	// ErrPassphraseRequired is returned when a value was encrypted with a
+	// passphrase but none was given.
+	ErrPassphraseRequired = errors.New("API key is protected by a passphrase")
+	// ErrDecrypt is returned when a value cannot be decrypted, e.g. because
+	// the passphrase is wrong or the config file comes from another user
+	// or machine.
+	ErrDecrypt = errors.New("failed to decrypt API key")
 )
 
 // deriveKey creates a 32-byte key from machine-specific data
@@ -18,8 +66,116 @@ func deriveKey() []byte {
 	return hash[:]
 }
 
+// EnvelopeScheme reports which scheme encrypted cipherText.
+func EnvelopeScheme(cipherText string) string {
+	switch {
+	case strings.HasPrefix(cipherText, envelopePrefix+"argon2id$"):
+		return SchemePassphrase
+	case strings.HasPrefix(cipherText, envelopePrefix+"home$"):
+		return SchemeMachine
+	}
+	return SchemeLegacy
+}
+
+// EncryptString encrypts plain with the machine-derived key.
 func EncryptString(plain string) (string, error) {
-	block, err := aes.NewCipher(deriveKey())
+	header := envelopePrefix + "home$"
+	sealed, err := seal(deriveKey(), []byte(plain), []byte(header))
+	if err != nil {
+		return "", err
+	}
+	return header + sealed, nil
+}
+
+// EncryptStringWithPassphrase encrypts plain with a key derived from
+// passphrase and a random salt.
+func EncryptStringWithPassphrase(plain, passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", ErrPassphraseRequired
+	}
+
+	salt := make([]byte, argonSaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", err
+	}
+
+	params := fmt.Sprintf("m=%d,t=%d,p=%d", argonMemory, argonTime, argonThreads)
+	header := envelopePrefix + "argon2id$" + params + "$" + base64.StdEncoding.EncodeToString(salt) + "$"
+	key := argon2.IDKey([]byte(passphrase), salt, argonTime, argonMemory, argonThreads, 32)
+	sealed, err := seal(key, []byte(plain), []byte(header))
+	if err != nil {
+		return "", err
+	}
+	return header + sealed, nil
+}
+
+// DecryptString decrypts a value written by EncryptString, or by older
+// versions of the CLI. Passphrase envelopes return ErrPassphraseRequired.
+func DecryptString(cipherText string) (string, error) {
+	return DecryptStringWithPassphrase(cipherText, "")
+}
+
+// DecryptStringWithPassphrase decrypts a value of any scheme, using
+// passphrase for passphrase envelopes.
+func DecryptStringWithPassphrase(cipherText, passphrase string) (string, error) {
+	switch EnvelopeScheme(cipherText) {
+	case SchemeLegacy:
+		plain, err := open(deriveKey(), cipherText, nil)
+		if err != nil {
+			return "", fmt.Errorf("%w: it was encrypted for another user or machine; set it again with: ipgeolocation config --apikey=<your-key>", ErrDecrypt)
+		}
+		return plain, nil
+
+	case SchemeMachine:
+		header := envelopePrefix + "home$"
+		plain, err := open(deriveKey(), strings.TrimPrefix(cipherText, header), []byte(header))
+		if err != nil {
+			return "", fmt.Errorf("%w: it was encrypted for another user or machine; set it again with: ipgeolocation config --apikey=<your-key>", ErrDecrypt)
+		}
+		return plain, nil
+	}
+
+	if passphrase == "" {
+		return "", ErrPassphraseRequired
+	}
+
+	// $ipg2$argon2id$<params>$<salt>$<data>
+	parts := strings.Split(strings.TrimPrefix(cipherText, envelopePrefix), "$")
+	if len(parts) != 4 {
+		return "", fmt.Errorf("%w: malformed envelope", ErrDecrypt)
+	}
+	var memory, passes uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &memory, &passes, &threads); err != nil || !validArgonParams(memory, passes, threads) {
+		return "", fmt.Errorf("%w: malformed argon2id parameters", ErrDecrypt)
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed salt", ErrDecrypt)
+	}
+
+	header := cipherText[:len(cipherText)-len(parts[3])]
+	key := argon2.IDKey([]byte(passphrase), salt, passes, memory, threads, 32)
+	plain, err := open(key, parts[3], []byte(header))
+	if err != nil {
+		return "", fmt.Errorf("%w: wrong passphrase", ErrDecrypt)
+	}
+	return plain, nil
+}
+
+// validArgonParams reports whether argon2id parameters read from an
+// envelope are within what the CLI writes and accepts: at least one pass
+// and thread, and at least 8 KiB of memory per thread (as RFC 9106
+// requires) but no more than argonMaxMemory.
+func validArgonParams(memory, passes uint32, threads uint8) bool {
+	return passes >= 1 && passes <= argonMaxTime &&
+		threads >= 1 &&
+		memory >= 8*uint32(threads) && memory <= argonMaxMemory
+}
+
+// seal encrypts plain with AES-GCM and returns base64(nonce || ciphertext).
+func seal(key, plain, additional []byte) (string, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
@@ -34,17 +190,18 @@ func EncryptString(plain string) (string, error) {
 		return "", err
 	}
 
-	cipherText := aesGCM.Seal(nonce, nonce, []byte(plain), nil)
+	cipherText := aesGCM.Seal(nonce, nonce, plain, additional)
 	return base64.StdEncoding.EncodeToString(cipherText), nil
 }
 
-func DecryptString(cipherText string) (string, error) {
-	data, err := base64.StdEncoding.DecodeString(cipherText)
+// open reverses seal.
+func open(key []byte, encoded string, additional []byte) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(deriveKey())
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
@@ -60,7 +217,7 @@ func DecryptString(cipherText string) (string, error) {
 	}
 
 	nonce, cipherTextBytes := data[:nonceSize], data[nonceSize:]
-	plain, err := aesGCM.Open(nil, nonce, cipherTextBytes, nil)
+	plain, err := aesGCM.Open(nil, nonce, cipherTextBytes, additional)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// vectorEnvelope encrypts vectorPlain with vectorPassphrase, using small
+// argon2id parameters to keep the test fast.
+const (
+	vectorEnvelope   = "$ipg2$argon2id$m=64,t=1,p=1$0555EEZwe67xmEfuyjp63Q==$ub5phcTu+kokHgO+avM/oiz9UOTevZc+6ynMesbQAWn+o7FNU6NlDRImIQWU6L6G9+HJsYo1fjw8YeZy"
+	vectorPassphrase = "correct horse"
+	vectorPlain      = "0123456789abcdef0123456789abcdef"
+)
+
+func TestDecryptVector(t *testing.T) {
+	plain, err := DecryptStringWithPassphrase(vectorEnvelope, vectorPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain != vectorPlain {
+		t.Errorf("got %q, want %q", plain, vectorPlain)
+	}
+
+	if _, err := DecryptStringWithPassphrase(vectorEnvelope, "wrong"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong passphrase: got %v, want ErrDecrypt", err)
+	}
+	if _, err := DecryptString(vectorEnvelope); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("no passphrase: got %v, want ErrPassphraseRequired", err)
+	}
+
+	// The header is authenticated: changing a parameter breaks decryption.
+	tampered := strings.Replace(vectorEnvelope, "t=1", "t=2", 1)
+	if _, err := DecryptStringWithPassphrase(tampered, vectorPassphrase); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("tampered header: got %v, want ErrDecrypt", err)
+	}
+}
+
+func TestDecryptRejectsBadArgonParams(t *testing.T) {
+	for _, params := range []string{
+		"m=1,t=0,p=0",
+		"m=64,t=0,p=1",
+		"m=64,t=1,p=0",
+		"m=7,t=1,p=1",
+		"m=64,t=1,p=16",
+		"m=4294967295,t=1,p=1",
+		"m=1048577,t=1,p=1",
+		"m=64,t=4294967295,p=1",
+		"m=64,t=1,p=256",
+		"m=-1,t=1,p=1",
+		"m=64,t=1",
+	} {
+		envelope := "$ipg2$argon2id$" + params + "$AAAA$AAAA"
+		_, err := DecryptStringWithPassphrase(envelope, vectorPassphrase)
+		if !errors.Is(err, ErrDecrypt) || !strings.Contains(err.Error(), "malformed argon2id parameters") {
+			t.Errorf("%s: got %v, want malformed argon2id parameters", params, err)
+		}
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	envelope, err := EncryptStringWithPassphrase(vectorPlain, vectorPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if EnvelopeScheme(envelope) != SchemePassphrase {
+		t.Errorf("scheme %q, want %q", EnvelopeScheme(envelope), SchemePassphrase)
+	}
+	if plain, err := DecryptStringWithPassphrase(envelope, vectorPassphrase); err != nil || plain != vectorPlain {
+		t.Errorf("got %q, %v", plain, err)
+	}
+
+	envelope, err = EncryptString(vectorPlain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if EnvelopeScheme(envelope) != SchemeMachine {
+		t.Errorf("scheme %q, want %q", EnvelopeScheme(envelope), SchemeMachine)
+	}
+	if plain, err := DecryptString(envelope); err != nil || plain != vectorPlain {
+		t.Errorf("got %q, %v", plain, err)
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"sort"
+
+	"github.com/IPGeolocation/cli/v2/client"
+)
+
+// Members returns the keys of a decoded JSON object, in order, and its
+// values. The keys of a plain map are sorted. ok is false when v is not an
+// object.
+func Members(v interface{}) (keys []string, values map[string]interface{}, ok bool) {
+	switch v := v.(type) {
+	case *client.OrderedMap:
+		return v.Keys, v.Values, true
+	case map[string]interface{}:
+		keys = make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys, v, true
+	}
+	return nil, nil, false
+}
+
+// SortKeys sorts the keys of every object in v, recursively.
+func SortKeys(v interface{}) {
+	switch v := v.(type) {
+	case *client.OrderedMap:
+		sort.Strings(v.Keys)
+		for _, value := range v.Values {
+			SortKeys(value)
+		}
+	case map[string]interface{}:
+		for _, value := range v {
+			SortKeys(value)
+		}
+	case []interface{}:
+		for _, item := range v {
+			SortKeys(item)
+		}
+	}
+}
@@ -2,30 +2,38 @@ package utils
 
 import (
 	"fmt"
+	"io"
 	"strings"
+
+	"github.com/IPGeolocation/cli/v2/client"
 )
 
-func PrintAsTable(data interface{}, indent int) {
+// FprintAsTable writes data to w as an indented list of fields.
+func FprintAsTable(w io.Writer, data interface{}, indent int) {
 	indentStr := strings.Repeat("  ", indent)
 
-	switch val := data.(type) {
-	case map[string]interface{}:
-		for key, value := range val {
+	if keys, values, ok := Members(data); ok {
+		for _, key := range keys {
+			value := values[key]
 			switch value.(type) {
-			case map[string]interface{}, []interface{}:
-				fmt.Printf("%s%s:\n", indentStr, ToTitle(key))
-				PrintAsTable(value, indent+1)
+			case *client.OrderedMap, map[string]interface{}, []interface{}:
+				fmt.Fprintf(w, "%s%s:\n", indentStr, ToTitle(key))
+				FprintAsTable(w, value, indent+1)
 			default:
-				fmt.Printf("%s%-20s: %v\n", indentStr, ToTitle(key), value)
+				fmt.Fprintf(w, "%s%-20s: %v\n", indentStr, ToTitle(key), value)
 			}
 		}
+		return
+	}
+
+	switch val := data.(type) {
 	case []interface{}:
 		for i, item := range val {
-			fmt.Printf("%s[%d]:\n", indentStr, i)
-			PrintAsTable(item, indent+1)
+			fmt.Fprintf(w, "%s[%d]:\n", indentStr, i)
+			FprintAsTable(w, item, indent+1)
 		}
 	default:
-		fmt.Printf("%s%v\n", indentStr, val)
+		fmt.Fprintf(w, "%s%v\n", indentStr, val)
 	}
 }
 
@@ -38,7 +46,3 @@ func ToTitle(s string) string {
 	}
 	return strings.Join(parts, " ")
 }
-
-func ConvertToXML(data interface{}) string {
-	return ""
-}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// XMLItem is the element name of array entries that have no key of their
+// own: those of a top-level array and of arrays nested in arrays.
+const XMLItem = "item"
+
+// ConvertToXML converts data decoded from JSON into an indented XML
+// document whose root element is named after root, e.g. the endpoint.
+//
+// Object members become child elements, in the order given by Members.
+// Array members are repeated elements named after the array's key, and an
+// empty array an empty element with array="true". Keys that are not valid
+// XML names are sanitized, and the original key is kept in a "key"
+// attribute. null becomes an empty element with nil="true".
+func ConvertToXML(root string, data interface{}) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	writeXML(&b, root, data, 0)
+	return b.String()
+}
+
+func writeXML(b *strings.Builder, key string, value interface{}, depth int) {
+	name := XMLName(key)
+	indent := strings.Repeat("  ", depth)
+
+	open := name
+	if name != key {
+		var attr strings.Builder
+		xml.EscapeText(&attr, []byte(key))
+		open += ` key="` + attr.String() + `"`
+	}
+
+	if keys, values, ok := Members(value); ok {
+		if len(keys) == 0 {
+			b.WriteString(indent + "<" + open + "/>\n")
+			return
+		}
+		b.WriteString(indent + "<" + open + ">\n")
+		for _, k := range keys {
+			writeMember(b, k, values[k], depth+1)
+		}
+		b.WriteString(indent + "</" + name + ">\n")
+		return
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		// Only reached for the root, for arrays nested in arrays and for
+		// empty arrays; other arrays in objects are expanded by writeMember.
+		if len(v) == 0 {
+			b.WriteString(indent + "<" + open + ` array="true"/>` + "\n")
+			return
+		}
+		b.WriteString(indent + "<" + open + ">\n")
+		for _, item := range v {
+			writeXML(b, XMLItem, item, depth+1)
+		}
+		b.WriteString(indent + "</" + name + ">\n")
+	case nil:
+		b.WriteString(indent + "<" + open + ` nil="true"/>` + "\n")
+	default:
+		b.WriteString(indent + "<" + open + ">")
+		xml.EscapeText(b, []byte(xmlScalar(v)))
+		b.WriteString("</" + name + ">\n")
+	}
+}
+
+// writeMember writes an object member or array entry. An array is written
+// as one element per entry, all named after key; an entry that is itself an
+// array becomes one element holding "item" elements. An empty array is a
+// single empty element marked array="true", so that it is not lost.
+func writeMember(b *strings.Builder, key string, value interface{}, depth int) {
+	items, ok := value.([]interface{})
+	if !ok || len(items) == 0 {
+		writeXML(b, key, value, depth)
+		return
+	}
+	for _, item := range items {
+		writeXML(b, key, item, depth)
+	}
+}
+
+func xmlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// XMLName turns s into a valid XML element name: characters other than
+// letters, digits, '-', '_' and '.' become '_', and names that start with
+// something other than a letter or '_', or with "xml" (reserved by the XML
+// specification), get a leading '_'. The API's endpoint paths such as
+// "timezone/convert" become "timezone_convert".
+func XMLName(s string) string {
+	if s == "" {
+		return "_"
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	name := b.String()
+	first, _ := utf8.DecodeRuneInString(name)
+	if !unicode.IsLetter(first) && first != '_' || strings.HasPrefix(strings.ToLower(name), "xml") {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IPGeolocation/cli/v2/client"
+)
+
+// xmlNode is a generic XML element, as parsed back by the tests.
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []xmlNode  `xml:",any"`
+	Text    string     `xml:",chardata"`
+}
+
+func (n xmlNode) attr(name string) (string, bool) {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// key is the JSON key the element was written for.
+func (n xmlNode) key() string {
+	if k, ok := n.attr("key"); ok {
+		return k
+	}
+	return n.XMLName.Local
+}
+
+// roundTrip renders data, checks that the result is well-formed and
+// returns its root element.
+func roundTrip(t *testing.T, root string, data interface{}) xmlNode {
+	t.Helper()
+	out := ConvertToXML(root, data)
+	if !strings.HasPrefix(out, xml.Header) {
+		t.Fatalf("missing XML header:\n%s", out)
+	}
+	var n xmlNode
+	if err := xml.Unmarshal([]byte(out), &n); err != nil {
+		t.Fatalf("output is not well-formed: %v\n%s", err, out)
+	}
+	return n
+}
+
+// checkXML reports where n does not hold value as written by ConvertToXML.
+func checkXML(t *testing.T, path string, value interface{}, n xmlNode) {
+	t.Helper()
+	if keys, values, ok := Members(value); ok {
+		i := 0
+		for _, k := range keys {
+			items, isArray := values[k].([]interface{})
+			if !isArray || len(items) == 0 {
+				items = []interface{}{values[k]}
+			}
+			for _, item := range items {
+				if i >= len(n.Nodes) || n.Nodes[i].key() != k {
+					t.Errorf("%s: missing or misplaced element for %q", path, k)
+					return
+				}
+				checkXML(t, path+"."+k, item, n.Nodes[i])
+				i++
+			}
+		}
+		if i != len(n.Nodes) {
+			t.Errorf("%s: %d elements for %d values", path, len(n.Nodes), i)
+		}
+		return
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		if len(v) == 0 {
+			if a, _ := n.attr("array"); a != "true" || len(n.Nodes) != 0 {
+				t.Errorf("%s: empty array written as %+v", path, n)
+			}
+			return
+		}
+		if len(n.Nodes) != len(v) {
+			t.Errorf("%s: %d elements for %d array entries", path, len(n.Nodes), len(v))
+			return
+		}
+		for i, item := range v {
+			if n.Nodes[i].XMLName.Local != XMLItem {
+				t.Errorf("%s[%d]: element <%s>, want <%s>", path, i, n.Nodes[i].XMLName.Local, XMLItem)
+			}
+			checkXML(t, path+"[]", item, n.Nodes[i])
+		}
+	case nil:
+		if a, _ := n.attr("nil"); a != "true" {
+			t.Errorf("%s: null written without nil=\"true\"", path)
+		}
+	default:
+		if want := xmlScalar(v); n.Text != want {
+			t.Errorf("%s: text %q, want %q", path, n.Text, want)
+		}
+	}
+}
+
+func TestConvertToXMLFixtures(t *testing.T) {
+	tests := []struct {
+		file, root, element string
+	}{
+		{"ipgeo.json", "ipgeo", "ipgeo"},
+		{"ipgeo-bulk.json", "ipgeo-bulk", "ipgeo-bulk"},
+		{"timezone-convert.json", "timezone/convert", "timezone_convert"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := client.DecodeJSON(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := roundTrip(t, tt.root, data)
+			if n.XMLName.Local != tt.element {
+				t.Errorf("root element <%s>, want <%s>", n.XMLName.Local, tt.element)
+			}
+			if n.key() != tt.root {
+				t.Errorf("root key %q, want %q", n.key(), tt.root)
+			}
+			checkXML(t, tt.root, data, n)
+		})
+	}
+}
+
+func TestConvertToXMLEdgeCases(t *testing.T) {
+	body := `{
+		"escaped": "<b> & \"quoted\" 'text' ]]>",
+		"a<b>": "sanitized",
+		"1st": 1,
+		"xml_version": "reserved",
+		"with space": true,
+		"missing": null,
+		"languages": [],
+		"empty": {},
+		"matrix": [[1, 2], [], [[3]]],
+		"objects": [{"k": "v"}, {"k": null}],
+		"nested": [null, "x & y"]
+	}`
+	data, err := client.DecodeJSON([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := roundTrip(t, "edge", data)
+	checkXML(t, "edge", data, n)
+
+	names := map[string]string{}
+	for _, child := range n.Nodes {
+		names[child.key()] = child.XMLName.Local
+	}
+	for key, want := range map[string]string{
+		"a<b>":        "a_b_",
+		"1st":         "_1st",
+		"xml_version": "_xml_version",
+		"with space":  "with_space",
+		"languages":   "languages",
+	} {
+		if names[key] != want {
+			t.Errorf("key %q written as <%s>, want <%s>", key, names[key], want)
+		}
+	}
+}
+
+func TestConvertToXMLRootArray(t *testing.T) {
+	data, err := client.DecodeJSON([]byte(`[]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := roundTrip(t, "ipgeo-bulk", data)
+	checkXML(t, "ipgeo-bulk", data, n)
+}
+
+func TestXMLName(t *testing.T) {
+	tests := map[string]string{
+		"ip":               "ip",
+		"":                 "_",
+		"timezone/convert": "timezone_convert",
+		"XMLish":           "_XMLish",
+		"-dash":            "_-dash",
+		"café":             "café",
+	}
+	for in, want := range tests {
+		if got := XMLName(in); got != want {
+			t.Errorf("XMLName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}